@@ -0,0 +1,245 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "os"
+    "unicode/utf8"
+)
+
+const (
+    KEY_CTRL_A = 1
+    KEY_CTRL_B = 2
+    KEY_CTRL_C = 3
+    KEY_CTRL_D = 4
+    KEY_CTRL_E = 5
+    KEY_CTRL_F = 6
+    KEY_CTRL_K = 11
+    KEY_CTRL_N = 14
+    KEY_CTRL_P = 16
+    KEY_CTRL_U = 21
+    KEY_ENTER = 13
+    KEY_ESC = 27
+    KEY_BACKSPACE = 127
+    KEY_CTRL_H = 8
+)
+
+// ErrInterrupted is returned when the line is cancelled by Ctrl-C
+var ErrInterrupted = fmt.Errorf("interrupted")
+
+// LineEditor reads lines with history and basic editing keys when the
+// input is a terminal, otherwise it falls back to plain line reading
+type LineEditor struct {
+    in *os.File
+    out io.Writer
+    reader *bufio.Reader
+
+    history []string
+    isTerminal bool
+}
+
+func NewLineEditor(in *os.File, out io.Writer) *LineEditor {
+    return &LineEditor {
+        in: in,
+        out: out,
+        reader: bufio.NewReader(in),
+        history: make([]string, 0),
+        isTerminal: IsTerminal(in.Fd()),
+    }
+}
+
+func (l *LineEditor) AddHistory(line string) {
+    if line == "" {
+        return
+    }
+
+    if len(l.history) > 0 && l.history[len(l.history)-1] == line {
+        return
+    }
+
+    l.history = append(l.history, line)
+}
+
+// read one line without the trailing newline. io.EOF is returned at the
+// end of input, or when Ctrl-D is pressed on an empty line
+func (l *LineEditor) ReadLine(prompt string) (string, error) {
+    fmt.Fprint(l.out, prompt)
+
+    if l.isTerminal {
+        if restore, err := MakeRaw(l.in.Fd()); err == nil {
+            defer restore()
+            return l.EditLine(prompt)
+        }
+    }
+
+    line, err := l.reader.ReadString('\n')
+    if err != nil && (err != io.EOF || line == "") {
+        return "", err
+    }
+
+    if len(line) > 0 && line[len(line)-1] == '\n' {
+        line = line[:len(line)-1]
+    }
+    if len(line) > 0 && line[len(line)-1] == '\r' {
+        line = line[:len(line)-1]
+    }
+
+    return line, nil
+}
+
+func (l *LineEditor) ReadKey() (rune, error) {
+    r, _, err := l.reader.ReadRune()
+    return r, err
+}
+
+// redraw the line and place the cursor
+func (l *LineEditor) Refresh(prompt string, buf []rune, pos int) {
+    fmt.Fprintf(l.out, "\r%s%s\x1b[K", prompt, string(buf))
+
+    col := utf8.RuneCountInString(prompt) + pos
+    fmt.Fprintf(l.out, "\r")
+    if col > 0 {
+        fmt.Fprintf(l.out, "\x1b[%dC", col)
+    }
+}
+
+func (l *LineEditor) EditLine(prompt string) (string, error) {
+    buf := []rune{}
+    pos := 0
+
+    // the index into history, len(history) is the line being edited
+    idx := len(l.history)
+    editing := ""
+
+    recall := func(i int) {
+        if i < 0 || i > len(l.history) {
+            return
+        }
+        if idx == len(l.history) {
+            editing = string(buf)
+        }
+
+        idx = i
+        if idx == len(l.history) {
+            buf = []rune(editing)
+        } else {
+            buf = []rune(l.history[idx])
+        }
+        pos = len(buf)
+    }
+
+    for {
+        r, err := l.ReadKey()
+        if err != nil {
+            return "", err
+        }
+
+        switch r {
+        case KEY_ENTER, '\n':
+            fmt.Fprint(l.out, "\r\n")
+            return string(buf), nil
+        case KEY_CTRL_C:
+            fmt.Fprint(l.out, "^C\r\n")
+            return "", ErrInterrupted
+        case KEY_CTRL_D:
+            if len(buf) == 0 {
+                fmt.Fprint(l.out, "\r\n")
+                return "", io.EOF
+            }
+            if pos < len(buf) {
+                buf = append(buf[:pos], buf[pos+1:]...)
+            }
+        case KEY_BACKSPACE, KEY_CTRL_H:
+            if pos > 0 {
+                buf = append(buf[:pos-1], buf[pos:]...)
+                pos--
+            }
+        case KEY_CTRL_A:
+            pos = 0
+        case KEY_CTRL_E:
+            pos = len(buf)
+        case KEY_CTRL_B:
+            if pos > 0 {
+                pos--
+            }
+        case KEY_CTRL_F:
+            if pos < len(buf) {
+                pos++
+            }
+        case KEY_CTRL_K:
+            buf = buf[:pos]
+        case KEY_CTRL_U:
+            buf = buf[pos:]
+            pos = 0
+        case KEY_CTRL_P:
+            recall(idx - 1)
+        case KEY_CTRL_N:
+            recall(idx + 1)
+        case KEY_ESC:
+            // escape sequences of the arrow keys, home, end and delete:
+            //   ESC [ A, ESC [ 3 ~, ESC O H
+            seq, err := l.ReadKey()
+            if err != nil {
+                return "", err
+            }
+            if seq != '[' && seq != 'O' {
+                break
+            }
+
+            key, err := l.ReadKey()
+            if err != nil {
+                return "", err
+            }
+
+            if key >= '0' && key <= '9' {
+                if tilde, err := l.ReadKey(); err != nil {
+                    return "", err
+                } else if tilde != '~' {
+                    break
+                }
+
+                switch key {
+                case '1', '7':
+                    pos = 0
+                case '4', '8':
+                    pos = len(buf)
+                case '3':
+                    if pos < len(buf) {
+                        buf = append(buf[:pos], buf[pos+1:]...)
+                    }
+                }
+                break
+            }
+
+            switch key {
+            case 'A':
+                recall(idx - 1)
+            case 'B':
+                recall(idx + 1)
+            case 'C':
+                if pos < len(buf) {
+                    pos++
+                }
+            case 'D':
+                if pos > 0 {
+                    pos--
+                }
+            case 'H':
+                pos = 0
+            case 'F':
+                pos = len(buf)
+            }
+        default:
+            if r < ' ' {
+                // ignore the other control keys
+                break
+            }
+
+            buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+            pos++
+        }
+
+        l.Refresh(prompt, buf, pos)
+    }
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
 )
 
-func Tokenize(fileContents []byte) {
-    scanner := NewScanner(string(fileContents))
+func Tokenize(fileContents []byte, reporter *lox.Reporter) {
+    scanner := lox.NewScanner(string(fileContents))
     tokens := scanner.ScanTokens()
 
+    for _, err := range(scanner.Errors) {
+        reporter.PrintError(err)
+    }
+
     for _, token := range(tokens) {
         fmt.Println(token.ToString())
     }
@@ -18,90 +26,166 @@ func Tokenize(fileContents []byte) {
     }
 }
 
-func Parse(fileContents []byte) {
-    scanner := NewScanner(string(fileContents))
+// print the syntax tree of the source, and return the exit status: 65 if
+// there is a lexical or a syntax error
+func Parse(fileContents []byte, reporter *lox.Reporter) int {
+    scanner := lox.NewScanner(string(fileContents))
     tokens := scanner.ScanTokens()
 
-    parser := NewParser(tokens)
-    expr, err := parser.ParseExpression()
-
-    if expr != nil {
-        fmt.Println(expr.String())
+    for _, err := range(scanner.Errors) {
+        reporter.PrintError(err)
     }
 
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "%s\n", err.Error())
-        os.Exit(65)
-    }
-}
+    // a program made of statements is printed statement by statement,
+    // otherwise the source is treated as a single expression
+    if IsProgram(tokens) {
+        parser := lox.NewParser(tokens)
+        stmts, err := parser.Parse()
+        if err != nil {
+            reporter.PrintError(err)
+            return 65
+        }
 
-func Evaluate(fileContents []byte) {
-    scanner := NewScanner(string(fileContents))
-    tokens := scanner.ScanTokens()
+        for _, stmt := range(stmts) {
+            fmt.Println(stmt.String())
+        }
+    } else {
+        parser := lox.NewParser(tokens)
+        expr, err := parser.ParseExpression()
 
-    parser := NewParser(tokens)
-    expr, _ := parser.ParseExpression()
+        if expr != nil {
+            fmt.Println(expr.String())
+        }
 
-    interpreter := NewInterpreter(expr)
-    value, err := interpreter.Eval()
+        if err != nil {
+            reporter.PrintError(err)
+            return 65
+        }
+    }
 
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "%s\n", err.Error())
-        os.Exit(70)
+    // the lexical errors fail the command as well, the same as tokenize
+    if scanner.HasError() {
+        return 65
     }
 
-    fmt.Println(value)
+    return 0
 }
 
-func Run(fileContents []byte) {
-    scanner := NewScanner(string(fileContents))
-    tokens := scanner.ScanTokens()
+// tell whether the source has the structure of statements: a semicolon,
+// a block or a keyword which begins a statement
+func IsProgram(tokens []lox.Token) bool {
+    for _, token := range(tokens) {
+        switch token.Type {
+        case lox.TK_SEMICOLON, lox.TK_LEFT_BRACE, lox.KW_CLASS, lox.KW_FUN, lox.KW_VAR, lox.KW_FOR, lox.KW_IF, lox.KW_WHILE, lox.KW_PRINT, lox.KW_RETURN:
+            return true
+        }
+    }
 
-    parser := NewParser(tokens)
-    stmts, err := parser.Parse()
+    return false
+}
 
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "%s\n", err.Error())
-        os.Exit(65)
+// the exit code of a failed run: 65 for the static errors, 70 for the runtime ones
+func ExitCode(err error) int {
+    if lox.IsCompileError(err) {
+        return 65
     }
 
-    for _, stmt := range(stmts) {
-        if err := stmt.Run(); err != nil {
-            fmt.Fprintf(os.Stderr, "%s\n", err.Error())
-            os.Exit(70)
-        }
-    }
+    return 70
 }
 
 func main() {
 	// You can use print statements as follows for debugging, they'll be visible when running tests.
 	fmt.Fprintln(os.Stderr, "Logs from your program will appear here!")
 
+	// start the REPL if no file is given
+	if len(os.Args) < 2 || os.Args[1] == "repl" {
+		Repl()
+		return
+	}
+
 	if len(os.Args) < 3 {
 		fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh tokenize <filename>")
 		os.Exit(1)
 	}
 
 	command := os.Args[1]
-	filename := os.Args[2]
+
+	// the flags precede the filename, for example: run --vm test.lox
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	useVM := flags.Bool("vm", false, "run with the bytecode virtual machine")
+	plainErrors := flags.Bool("plain-errors", false, "report errors in the canonical format without source excerpts")
+	timeout := flags.Duration("timeout", 0, "abort the program running longer than the duration, such as 500ms or 2s")
+	var limits lox.Limits
+	flags.Int64Var(&limits.MaxSteps, "max-steps", 0, "abort the program after the number of loop iterations and calls")
+	flags.IntVar(&limits.MaxCallDepth, "max-depth", 0, "the maximum number of nested calls (default 10000)")
+	flags.Int64Var(&limits.MaxMemory, "max-memory", 0, "abort the program allocating more bytes of strings and objects")
+
+	// the permissions of the natives, the directories may be repeated:
+	// --allow-read=data --allow-read=config
+	permissions := lox.DefaultPermissions
+	flags.Func("allow-read", "allow reading the files under the directory", func(dir string) error {
+		permissions.ReadRoots = append(permissions.ReadRoots, dir)
+		return nil
+	})
+	flags.Func("allow-write", "allow writing the files under the directory", func(dir string) error {
+		permissions.WriteRoots = append(permissions.WriteRoots, dir)
+		return nil
+	})
+	flags.BoolVar(&permissions.Env, "allow-env", false, "allow reading the environment variables")
+	flags.BoolVar(&permissions.Exec, "allow-exec", false, "allow running commands")
+	flags.BoolVar(&permissions.Clock, "allow-clock", true, "allow reading the clock")
+	flags.Parse(os.Args[2:])
+
+	if flags.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: ./your_program.sh %s <filename>\n", command)
+		os.Exit(1)
+	}
+
+	filename := flags.Arg(0)
 	fileContents, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 		os.Exit(1)
 	}
 
+	in := lox.New(lox.WithFile(filename), lox.WithVM(*useVM), lox.WithPlainErrors(*plainErrors), lox.WithLimits(limits), lox.WithPermissions(permissions))
+
+	reporter := lox.NewReporter(os.Stderr)
+	reporter.Source = string(fileContents)
+	reporter.Plain = *plainErrors
+
     switch command {
     case "tokenize":
-        Tokenize(fileContents)
+        Tokenize(fileContents, reporter)
         return
     case "parse":
-        Parse(fileContents)
+        if status := Parse(fileContents, reporter); status != 0 {
+            os.Exit(status)
+        }
         return
     case "evaluate":
-        Evaluate(fileContents)
+        v, err := in.Eval(string(fileContents))
+        if err != nil {
+            os.Exit(ExitCode(err))
+        }
+        fmt.Println(v)
         return
     case "run":
-        Run(fileContents)
+        ctx := context.Background()
+        if *timeout > 0 {
+            var cancel context.CancelFunc
+            ctx, cancel = context.WithTimeout(ctx, *timeout)
+            defer cancel()
+        }
+
+        if err := in.RunStringContext(ctx, string(fileContents)); err != nil {
+            os.Exit(ExitCode(err))
+        }
+        return
+    case "disassemble":
+        if err := in.Disassemble(string(fileContents)); err != nil {
+            os.Exit(ExitCode(err))
+        }
         return
     }
 
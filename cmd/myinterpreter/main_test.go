@@ -0,0 +1,49 @@
+package main
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+func TestParseReportsAllErrors(t *testing.T) {
+    tests := []struct {
+        source string
+        want string
+        status int
+    }{
+        {
+            "@ print 1;\n",
+            "[line 1] Error: Unexpected character: @\n",
+            65,
+        },
+        {
+            "@ print 1;\nprint 1 +;\n",
+            "[line 1] Error: Unexpected character: @\n[line 2] Error at ';': Expect expression.\n",
+            65,
+        },
+        {
+            "var a = 1;\nprint a;\nprint 1 +;\nvar = 2;\n",
+            "[line 3] Error at ';': Expect expression.\n[line 4] Error at '=': Expect variable name.\n",
+            65,
+        },
+        {
+            "print 1;\n",
+            "",
+            0,
+        },
+    }
+
+    for _, tt := range(tests) {
+        var errs bytes.Buffer
+        reporter := lox.NewReporter(&errs)
+        reporter.Source = tt.source
+        reporter.Plain = true
+
+        status := Parse([]byte(tt.source), reporter)
+        if status != tt.status || errs.String() != tt.want {
+            t.Errorf("Parse(%q) = %d, %q, want %d, %q", tt.source, status, errs.String(), tt.status, tt.want)
+        }
+    }
+}
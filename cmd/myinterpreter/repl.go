@@ -0,0 +1,94 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "os"
+
+    "github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// Repl runs a read-eval-print loop. the variables defined by one input
+// are visible to the following ones, and errors don't stop the loop
+func Repl() {
+    editor := NewLineEditor(os.Stdin, os.Stdout)
+    in := lox.New()
+
+    for {
+        source, err := ReadInput(editor)
+        if err == ErrInterrupted {
+            continue
+        }
+        if err == io.EOF {
+            return
+        }
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+            return
+        }
+
+        RunInput(in, source)
+    }
+}
+
+// read lines until all the braces are closed
+func ReadInput(editor *LineEditor) (string, error) {
+    line, err := editor.ReadLine("> ")
+    if err != nil {
+        return "", err
+    }
+    editor.AddHistory(line)
+
+    source := line
+    for OpenBraces(source) > 0 {
+        if line, err = editor.ReadLine("... "); err != nil {
+            return "", err
+        }
+        editor.AddHistory(line)
+
+        source = source + "\n" + line
+    }
+
+    return source, nil
+}
+
+// the number of braces not closed yet, ignoring the ones in strings and
+// comments. a string not closed yet, which may span lines, counts as one
+func OpenBraces(source string) int {
+    depth := 0
+    for i := 0; i < len(source); i++ {
+        switch {
+        case source[i] == '"':
+            for i++; i < len(source) && source[i] != '"'; i++ {
+                if source[i] == '\\' {
+                    i++
+                }
+            }
+            if i >= len(source) {
+                return depth + 1
+            }
+        case source[i] == '/' && i + 1 < len(source) && source[i+1] == '/':
+            for ; i < len(source) && source[i] != '\n'; i++ {
+            }
+        case source[i] == '{':
+            depth++
+        case source[i] == '}':
+            depth--
+        }
+    }
+
+    return depth
+}
+
+// a bare expression without semicolon is evaluated and printed, the same
+// as the evaluate command. the errors are reported by the interpreter
+func RunInput(in *lox.Interpreter, source string) {
+    if !in.IsExpression(source) {
+        in.RunString(source)
+        return
+    }
+
+    if value, err := in.Eval(source); err == nil {
+        fmt.Println(value)
+    }
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package main
+
+import (
+    "syscall"
+    "unsafe"
+)
+
+func getTermios(fd uintptr) (*syscall.Termios, error) {
+    termios := &syscall.Termios{}
+    if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(termios))); errno != 0 {
+        return nil, errno
+    }
+
+    return termios, nil
+}
+
+func setTermios(fd uintptr, termios *syscall.Termios) error {
+    if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(termios))); errno != 0 {
+        return errno
+    }
+
+    return nil
+}
+
+// IsTerminal reports whether the file descriptor refers to a terminal
+func IsTerminal(fd uintptr) bool {
+    _, err := getTermios(fd)
+    return err == nil
+}
+
+// put the terminal into raw mode, so that the keys are read one by one
+// without echo. the returned function restores the previous mode
+func MakeRaw(fd uintptr) (func(), error) {
+    old, err := getTermios(fd)
+    if err != nil {
+        return nil, err
+    }
+
+    raw := *old
+    raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+    raw.Cflag |= syscall.CS8
+    raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+    raw.Cc[syscall.VMIN] = 1
+    raw.Cc[syscall.VTIME] = 0
+
+    if err = setTermios(fd, &raw); err != nil {
+        return nil, err
+    }
+
+    return func() {
+        setTermios(fd, old)
+    }, nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package main
+
+import (
+    "fmt"
+)
+
+// the line editing is only supported on linux, other platforms read the
+// input line by line without editing
+func IsTerminal(fd uintptr) bool {
+    return false
+}
+
+func MakeRaw(fd uintptr) (func(), error) {
+    return nil, fmt.Errorf("raw mode is not supported")
+}
@@ -0,0 +1,203 @@
+package lox
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "math/big"
+    "strconv"
+)
+
+// the arbitrary precision numbers: the big integers, 123n, and the
+// decimals, 1.10d. they are exact, so they are never mixed with the
+// floating point numbers implicitly, see the bigint(), decimal() and
+// float() natives for the explicit conversions
+
+// the fraction digits printed for the decimals without a finite decimal
+// expansion, such as 1d / 3d
+const DecimalPrecision = 20
+
+// the decimals are printed with as many fraction digits as they need:
+// 1.1, 3, -0.25, 0.33333333333333333333
+func FormatDecimal(r *big.Rat) string {
+    if n, exact := r.FloatPrec(); exact {
+        return r.FloatString(n)
+    }
+
+    return r.FloatString(DecimalPrecision)
+}
+
+var bigOne = big.NewInt(1)
+
+// the size in bits beyond which the powers and the shifts of the big
+// numbers fail, rather than exhaust the memory
+const MaxBigBits = 1 << 24
+
+var ErrTooLarge = errors.New("Result too large.")
+var ErrDecimalExponent = errors.New("Exponent of a decimal must be an integer.")
+
+// the power of a big integer, the exponent is not negative
+func PowBig(a *big.Int, e int64) (*big.Int, error) {
+    if a.CmpAbs(bigOne) > 0 && e > MaxBigBits / int64(a.BitLen()) {
+        return nil, ErrTooLarge
+    }
+
+    return new(big.Int).Exp(a, big.NewInt(e), nil), nil
+}
+
+// the power of a decimal, a negative exponent inverts it
+func PowRat(a *big.Rat, e int64) (*big.Rat, error) {
+    if e < 0 {
+        if a.Sign() == 0 {
+            return nil, ErrDivisionByZero
+        }
+        a, e = new(big.Rat).Inv(a), -e
+    }
+
+    num, err := PowBig(a.Num(), e)
+    if err != nil {
+        return nil, err
+    }
+    denom, err := PowBig(a.Denom(), e)
+    if err != nil {
+        return nil, err
+    }
+
+    return new(big.Rat).SetFrac(num, denom), nil
+}
+
+func ShiftLeftBig(a, n *big.Int) (*big.Int, error) {
+    if n.Sign() < 0 {
+        return nil, ErrNegativeShift
+    }
+    if a.Sign() == 0 {
+        return new(big.Int), nil
+    }
+    if !n.IsInt64() || n.Int64() > MaxBigBits - int64(a.BitLen()) {
+        return nil, ErrTooLarge
+    }
+
+    return new(big.Int).Lsh(a, uint(n.Int64())), nil
+}
+
+func ShiftRightBig(a, n *big.Int) (*big.Int, error) {
+    if n.Sign() < 0 {
+        return nil, ErrNegativeShift
+    }
+
+    // shifting out all the bits leaves the sign
+    if !n.IsInt64() || n.Int64() >= int64(a.BitLen()) {
+        if a.Sign() < 0 {
+            return big.NewInt(-1), nil
+        }
+        return new(big.Int), nil
+    }
+
+    return new(big.Int).Rsh(a, uint(n.Int64())), nil
+}
+
+func FloorDivBig(a, b *big.Int) (*big.Int, error) {
+    if b.Sign() == 0 {
+        return nil, ErrDivisionByZero
+    }
+
+    q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+    if r.Sign() != 0 && (r.Sign() < 0) != (b.Sign() < 0) {
+        q.Sub(q, bigOne)
+    }
+
+    return q, nil
+}
+
+func FloorModBig(a, b *big.Int) (*big.Int, error) {
+    if b.Sign() == 0 {
+        return nil, ErrDivisionByZero
+    }
+
+    _, r := new(big.Int).QuoRem(a, b, new(big.Int))
+    if r.Sign() != 0 && (r.Sign() < 0) != (b.Sign() < 0) {
+        r.Add(r, b)
+    }
+
+    return r, nil
+}
+
+func QuoRat(a, b *big.Rat) (*big.Rat, error) {
+    if b.Sign() == 0 {
+        return nil, ErrDivisionByZero
+    }
+
+    return new(big.Rat).Quo(a, b), nil
+}
+
+// the largest integer not greater than the decimal. the denominator is
+// positive, so the euclidean division is floored
+func FloorRat(r *big.Rat) *big.Rat {
+    return new(big.Rat).SetInt(new(big.Int).Div(r.Num(), r.Denom()))
+}
+
+func FloorDivRat(a, b *big.Rat) (*big.Rat, error) {
+    q, err := QuoRat(a, b)
+    if err != nil {
+        return nil, err
+    }
+
+    return FloorRat(q), nil
+}
+
+func FloorModRat(a, b *big.Rat) (*big.Rat, error) {
+    q, err := FloorDivRat(a, b)
+    if err != nil {
+        return nil, err
+    }
+
+    return new(big.Rat).Sub(a, q.Mul(q, b)), nil
+}
+
+// the decimal of a number is the one of its shortest representation, so
+// that 0.1 converts to 0.1d rather than to the exact binary fraction
+func FloatToDecimal(v float64) (*big.Rat, error) {
+    if math.IsNaN(v) || math.IsInf(v, 0) {
+        return nil, fmt.Errorf("Can't convert %s to decimal.", FormatNumber(v))
+    }
+
+    r, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+    return r, nil
+}
+
+// the big integer of a number truncated toward zero, the same as int()
+func FloatToBig(v float64) (*big.Int, error) {
+    if math.IsNaN(v) || math.IsInf(v, 0) {
+        return nil, fmt.Errorf("Can't convert %s to bigint.", FormatNumber(v))
+    }
+
+    n, _ := big.NewFloat(math.Trunc(v)).Int(nil)
+    return n, nil
+}
+
+// the integer part of a decimal, truncated toward zero
+func TruncRat(r *big.Rat) *big.Int {
+    return new(big.Int).Quo(r.Num(), r.Denom())
+}
+
+// tell whether the value is a big integer or a decimal
+func IsExact(v ValueType) bool {
+    switch v.(type) {
+    case BigIntType, DecimalType:
+        return true
+    }
+
+    return false
+}
+
+// the arithmetic mixing an exact number with a floating point one would be
+// inexact, so it's an error rather than a silent rounding
+func CheckExactMix(lhs, rhs ValueType) error {
+    _, lhsFloat := lhs.(NumberType)
+    _, rhsFloat := rhs.(NumberType)
+    if lhsFloat && IsExact(rhs) || rhsFloat && IsExact(lhs) {
+        return fmt.Errorf("Can't mix %s and %s, convert one of them explicitly.", lhs.Type(), rhs.Type())
+    }
+
+    return nil
+}
@@ -0,0 +1,166 @@
+package lox
+
+import (
+    "fmt"
+)
+
+// the instruction set of the virtual machine. the operands follow the opcode:
+//   const: 2 bytes index into the constant pool
+//   slot: 1 byte index of local variable or upvalue
+//   jump: 2 bytes unsigned offset
+//   argc: 1 byte number of arguments
+const (
+    OP_CONSTANT byte = iota    // const
+    OP_NIL
+    OP_TRUE
+    OP_FALSE
+    OP_POP
+    OP_GET_LOCAL               // slot
+    OP_SET_LOCAL               // slot
+    OP_GET_GLOBAL              // const
+    OP_DEFINE_GLOBAL           // const
+    OP_SET_GLOBAL              // const
+    OP_GET_UPVALUE             // slot
+    OP_SET_UPVALUE             // slot
+    OP_GET_PROPERTY            // const
+    OP_SET_PROPERTY            // const
+    OP_GET_SUPER               // const
+    OP_EQUAL
+    OP_NOT_EQUAL
+    OP_GREATER
+    OP_GREATER_EQUAL
+    OP_LESS
+    OP_LESS_EQUAL
+    OP_ADD
+    OP_SUBTRACT
+    OP_MULTIPLY
+    OP_DIVIDE
+    OP_INT_DIVIDE
+    OP_MODULO
+    OP_POWER
+    OP_BIT_AND
+    OP_BIT_OR
+    OP_BIT_XOR
+    OP_SHIFT_LEFT
+    OP_SHIFT_RIGHT
+    OP_NOT
+    OP_NEGATE
+    OP_BIT_NOT
+    OP_STRINGIFY
+    OP_PRINT
+    OP_JUMP                    // jump
+    OP_JUMP_IF_FALSE           // jump
+    OP_LOOP                    // jump
+    OP_CALL                    // argc
+    OP_CLOSURE                 // const, then (is_local, slot) for each upvalue
+    OP_CLOSE_UPVALUE
+    OP_RETURN
+    OP_CLASS                   // const
+    OP_INHERIT
+    OP_METHOD                  // const
+)
+
+var OpNames = map[byte]string{
+    OP_CONSTANT: "OP_CONSTANT",
+    OP_NIL: "OP_NIL",
+    OP_TRUE: "OP_TRUE",
+    OP_FALSE: "OP_FALSE",
+    OP_POP: "OP_POP",
+    OP_GET_LOCAL: "OP_GET_LOCAL",
+    OP_SET_LOCAL: "OP_SET_LOCAL",
+    OP_GET_GLOBAL: "OP_GET_GLOBAL",
+    OP_DEFINE_GLOBAL: "OP_DEFINE_GLOBAL",
+    OP_SET_GLOBAL: "OP_SET_GLOBAL",
+    OP_GET_UPVALUE: "OP_GET_UPVALUE",
+    OP_SET_UPVALUE: "OP_SET_UPVALUE",
+    OP_GET_PROPERTY: "OP_GET_PROPERTY",
+    OP_SET_PROPERTY: "OP_SET_PROPERTY",
+    OP_GET_SUPER: "OP_GET_SUPER",
+    OP_EQUAL: "OP_EQUAL",
+    OP_NOT_EQUAL: "OP_NOT_EQUAL",
+    OP_GREATER: "OP_GREATER",
+    OP_GREATER_EQUAL: "OP_GREATER_EQUAL",
+    OP_LESS: "OP_LESS",
+    OP_LESS_EQUAL: "OP_LESS_EQUAL",
+    OP_ADD: "OP_ADD",
+    OP_SUBTRACT: "OP_SUBTRACT",
+    OP_MULTIPLY: "OP_MULTIPLY",
+    OP_DIVIDE: "OP_DIVIDE",
+    OP_INT_DIVIDE: "OP_INT_DIVIDE",
+    OP_MODULO: "OP_MODULO",
+    OP_POWER: "OP_POWER",
+    OP_BIT_AND: "OP_BIT_AND",
+    OP_BIT_OR: "OP_BIT_OR",
+    OP_BIT_XOR: "OP_BIT_XOR",
+    OP_SHIFT_LEFT: "OP_SHIFT_LEFT",
+    OP_SHIFT_RIGHT: "OP_SHIFT_RIGHT",
+    OP_NOT: "OP_NOT",
+    OP_NEGATE: "OP_NEGATE",
+    OP_BIT_NOT: "OP_BIT_NOT",
+    OP_STRINGIFY: "OP_STRINGIFY",
+    OP_PRINT: "OP_PRINT",
+    OP_JUMP: "OP_JUMP",
+    OP_JUMP_IF_FALSE: "OP_JUMP_IF_FALSE",
+    OP_LOOP: "OP_LOOP",
+    OP_CALL: "OP_CALL",
+    OP_CLOSURE: "OP_CLOSURE",
+    OP_CLOSE_UPVALUE: "OP_CLOSE_UPVALUE",
+    OP_RETURN: "OP_RETURN",
+    OP_CLASS: "OP_CLASS",
+    OP_INHERIT: "OP_INHERIT",
+    OP_METHOD: "OP_METHOD",
+}
+
+// the maximum index of a 2 bytes operand
+const MaxShort = 0xffff
+
+// Chunk is a sequence of bytecode with its constant pool. the line, token
+// and span tables have one entry for each byte of code, so that the errors
+// are located the same as by the interpreter
+type Chunk struct {
+    Code []byte
+    Lines []int
+    Tokens []*Token
+    Spans []Span
+    Constants []ValueType
+}
+
+func NewChunk() *Chunk {
+    return &Chunk {
+        Code: make([]byte, 0),
+        Lines: make([]int, 0),
+        Tokens: make([]*Token, 0),
+        Spans: make([]Span, 0),
+        Constants: make([]ValueType, 0),
+    }
+}
+
+func (c *Chunk) Write(b byte, line int, token *Token, span Span) {
+    c.Code = append(c.Code, b)
+    c.Lines = append(c.Lines, line)
+    c.Tokens = append(c.Tokens, token)
+    c.Spans = append(c.Spans, span)
+}
+
+// add the value into the constant pool and return its index.
+// the strings are deduplicated since they're used as names frequently
+func (c *Chunk) AddConstant(v ValueType) (int, error) {
+    if str, ok := v.(StringType); ok {
+        for i, constant := range(c.Constants) {
+            if s, ok := constant.(StringType); ok && s.v == str.v {
+                return i, nil
+            }
+        }
+    }
+
+    if len(c.Constants) > MaxShort {
+        return 0, fmt.Errorf("Too many constants in one chunk.")
+    }
+
+    c.Constants = append(c.Constants, v)
+    return len(c.Constants) - 1, nil
+}
+
+func (c *Chunk) ReadShort(offset int) int {
+    return int(c.Code[offset]) << 8 | int(c.Code[offset + 1])
+}
@@ -0,0 +1,576 @@
+package lox
+
+import (
+    "fmt"
+)
+
+// the maximum number of local variables and upvalues in one function,
+// since their index is encoded in one byte
+const MaxLocals = 256
+
+type Local struct {
+    name string
+    depth int
+    isCaptured bool // captured by a closure, it must be closed at the end of scope
+}
+
+type UpvalueRef struct {
+    index byte
+    isLocal bool // captures a local of the enclosing function, or an upvalue of it
+}
+
+// the state of the function being compiled
+type FunctionCompiler struct {
+    enclosing *FunctionCompiler
+    function *FunctionObj
+    typ int
+
+    locals []Local
+    upvalues []UpvalueRef
+    scopeDepth int
+}
+
+// the state of the class being compiled
+type ClassCompiler struct {
+    enclosing *ClassCompiler
+    hasSuperclass bool
+}
+
+// Compiler translates the AST into bytecode. it runs after the resolver,
+// so the program is known to be semantically valid here
+type Compiler struct {
+    current *FunctionCompiler
+    currentClass *ClassCompiler
+
+    // the source line of the node being compiled, and the token and the
+    // span its runtime errors are located at
+    line int
+    token *Token
+    span Span
+
+    // the first error, such as too many local variables
+    err error
+}
+
+func NewCompiler() *Compiler {
+    return &Compiler {
+        line: 1,
+        span: Span{Line: 1},
+    }
+}
+
+// compile the program into the function of the top-level script
+func (c *Compiler) Compile(stmts []Stmt) (*FunctionObj, error) {
+    c.BeginFunction(FN_NONE, "")
+    for _, stmt := range(stmts) {
+        c.CompileStmt(stmt)
+    }
+    fn, _ := c.EndFunction()
+
+    if c.err != nil {
+        return nil, c.err
+    }
+
+    return fn, nil
+}
+
+// compile the expression into a top-level function returning its value
+func (c *Compiler) CompileExpression(expr Expr) (*FunctionObj, error) {
+    c.BeginFunction(FN_NONE, "")
+    c.CompileExpr(expr)
+    c.Emit(OP_RETURN)
+    fn, _ := c.EndFunction()
+
+    if c.err != nil {
+        return nil, c.err
+    }
+
+    return fn, nil
+}
+
+func (c *Compiler) Error(format string, args ...any) {
+    if c.err == nil {
+        c.err = &SpanError{
+            Span: Span{Line: c.line},
+            Message: fmt.Sprintf("[line %d] Error: %s", c.line, fmt.Sprintf(format, args...)),
+        }
+    }
+}
+
+func (c *Compiler) SetLine(tk *Token) {
+    c.SetLocation(tk, tk.Span())
+}
+
+// locate the following instructions the same as Locate does for the node
+func (c *Compiler) SetLocation(tk *Token, span Span) {
+    c.line = tk.Line
+    c.token = tk
+    c.span = span
+}
+
+func (c *Compiler) Chunk() *Chunk {
+    return c.current.function.chunk
+}
+
+func (c *Compiler) Emit(bytes ...byte) {
+    for _, b := range(bytes) {
+        c.Chunk().Write(b, c.line, c.token, c.span)
+    }
+}
+
+func (c *Compiler) EmitShort(op byte, operand int) {
+    c.Emit(op, byte(operand >> 8), byte(operand))
+}
+
+func (c *Compiler) MakeConstant(v ValueType) int {
+    idx, err := c.Chunk().AddConstant(v)
+    if err != nil {
+        c.Error("%s", err.Error())
+    }
+
+    return idx
+}
+
+func (c *Compiler) EmitConstant(v ValueType) {
+    c.EmitShort(OP_CONSTANT, c.MakeConstant(v))
+}
+
+// emit a jump with placeholder offset, and return the offset to patch
+func (c *Compiler) EmitJump(op byte) int {
+    c.Emit(op, 0xff, 0xff)
+    return len(c.Chunk().Code) - 2
+}
+
+// jump to the current position
+func (c *Compiler) PatchJump(offset int) {
+    jump := len(c.Chunk().Code) - offset - 2
+    if jump > MaxShort {
+        c.Error("Too much code to jump over.")
+    }
+
+    c.Chunk().Code[offset] = byte(jump >> 8)
+    c.Chunk().Code[offset + 1] = byte(jump)
+}
+
+// jump backward to the start of loop
+func (c *Compiler) EmitLoop(start int) {
+    offset := len(c.Chunk().Code) - start + 3
+    if offset > MaxShort {
+        c.Error("Loop body too large.")
+    }
+
+    c.EmitShort(OP_LOOP, offset)
+}
+
+func (c *Compiler) EmitReturn() {
+    // the initializer always returns `this` in the slot zero
+    if c.current.typ == FN_INITIALIZER {
+        c.Emit(OP_GET_LOCAL, 0)
+    } else {
+        c.Emit(OP_NIL)
+    }
+    c.Emit(OP_RETURN)
+}
+
+func (c *Compiler) BeginFunction(typ int, name string) {
+    fc := &FunctionCompiler {
+        enclosing: c.current,
+        function: &FunctionObj{name: name, chunk: NewChunk()},
+        typ: typ,
+        locals: make([]Local, 0, 8),
+        upvalues: make([]UpvalueRef, 0),
+    }
+
+    // the slot zero holds the callee, or `this` for methods
+    slot0 := ""
+    if typ == FN_METHOD || typ == FN_INITIALIZER {
+        slot0 = "this"
+    }
+    fc.locals = append(fc.locals, Local{name: slot0, depth: 0})
+
+    c.current = fc
+}
+
+func (c *Compiler) EndFunction() (*FunctionObj, []UpvalueRef) {
+    c.EmitReturn()
+
+    fc := c.current
+    fc.function.upvalueCount = len(fc.upvalues)
+    c.current = fc.enclosing
+
+    return fc.function, fc.upvalues
+}
+
+func (c *Compiler) BeginScope() {
+    c.current.scopeDepth++
+}
+
+func (c *Compiler) EndScope() {
+    fc := c.current
+    fc.scopeDepth--
+
+    for len(fc.locals) > 0 && fc.locals[len(fc.locals)-1].depth > fc.scopeDepth {
+        if fc.locals[len(fc.locals)-1].isCaptured {
+            c.Emit(OP_CLOSE_UPVALUE)
+        } else {
+            c.Emit(OP_POP)
+        }
+        fc.locals = fc.locals[:len(fc.locals)-1]
+    }
+}
+
+func (c *Compiler) AddLocal(name string) {
+    if len(c.current.locals) >= MaxLocals {
+        c.Error("Too many local variables in function.")
+        return
+    }
+
+    c.current.locals = append(c.current.locals, Local{name: name, depth: c.current.scopeDepth})
+}
+
+func ResolveLocal(fc *FunctionCompiler, name string) int {
+    for i := len(fc.locals) - 1; i >= 0; i-- {
+        if fc.locals[i].name == name {
+            return i
+        }
+    }
+
+    return -1
+}
+
+func (c *Compiler) AddUpvalue(fc *FunctionCompiler, index int, isLocal bool) int {
+    for i, upvalue := range(fc.upvalues) {
+        if int(upvalue.index) == index && upvalue.isLocal == isLocal {
+            return i
+        }
+    }
+
+    if len(fc.upvalues) >= MaxLocals {
+        c.Error("Too many closure variables in function.")
+        return 0
+    }
+
+    fc.upvalues = append(fc.upvalues, UpvalueRef{index: byte(index), isLocal: isLocal})
+    return len(fc.upvalues) - 1
+}
+
+// find the variable in the enclosing functions, and capture it
+func (c *Compiler) ResolveUpvalue(fc *FunctionCompiler, name string) int {
+    if fc.enclosing == nil {
+        return -1
+    }
+
+    if local := ResolveLocal(fc.enclosing, name); local != -1 {
+        fc.enclosing.locals[local].isCaptured = true
+        return c.AddUpvalue(fc, local, true)
+    }
+
+    if upvalue := c.ResolveUpvalue(fc.enclosing, name); upvalue != -1 {
+        return c.AddUpvalue(fc, upvalue, false)
+    }
+
+    return -1
+}
+
+// load the variable, or store the value on the stack top into it if assign
+func (c *Compiler) NamedVariable(name string, assign bool) {
+    if slot := ResolveLocal(c.current, name); slot != -1 {
+        if assign {
+            c.Emit(OP_SET_LOCAL, byte(slot))
+        } else {
+            c.Emit(OP_GET_LOCAL, byte(slot))
+        }
+    } else if slot := c.ResolveUpvalue(c.current, name); slot != -1 {
+        if assign {
+            c.Emit(OP_SET_UPVALUE, byte(slot))
+        } else {
+            c.Emit(OP_GET_UPVALUE, byte(slot))
+        }
+    } else {
+        idx := c.MakeConstant(StringType{v: name})
+        if assign {
+            c.EmitShort(OP_SET_GLOBAL, idx)
+        } else {
+            c.EmitShort(OP_GET_GLOBAL, idx)
+        }
+    }
+}
+
+// define the variable whose value is on the stack top. a local variable
+// just stays in its slot
+func (c *Compiler) DefineVariable(name string) {
+    if c.current.scopeDepth > 0 {
+        c.AddLocal(name)
+        return
+    }
+
+    c.EmitShort(OP_DEFINE_GLOBAL, c.MakeConstant(StringType{v: name}))
+}
+
+func (c *Compiler) CompileStmt(stmt Stmt) {
+    switch s := stmt.(type) {
+    case PrintStmt:
+        c.CompileExpr(s.v)
+        c.Emit(OP_PRINT)
+    case ExprStmt:
+        c.CompileExpr(s.v)
+        c.Emit(OP_POP)
+    case VarStmt:
+        if s.v != nil {
+            c.CompileExpr(s.v)
+        } else {
+            c.Emit(OP_NIL)
+        }
+        c.SetLine(s.tk)
+        c.DefineVariable(s.tk.Lexeme)
+    case BlockStmt:
+        c.BeginScope()
+        for _, stmt := range(s.stmts) {
+            c.CompileStmt(stmt)
+        }
+        c.EndScope()
+    case IfStmt:
+        c.CompileIf(s)
+    case WhileStmt:
+        c.CompileWhile(s)
+    case *FunStmt:
+        c.SetLine(s.name)
+        // a local function is visible in its own body, so that it can recurse
+        if c.current.scopeDepth > 0 {
+            c.AddLocal(s.name.Lexeme)
+            c.CompileFunction(s, FN_FUNCTION)
+        } else {
+            c.CompileFunction(s, FN_FUNCTION)
+            c.DefineVariable(s.name.Lexeme)
+        }
+    case ReturnStmt:
+        c.SetLine(s.keyword)
+        if s.v == nil {
+            c.EmitReturn()
+        } else {
+            c.CompileExpr(s.v)
+            c.Emit(OP_RETURN)
+        }
+    case ClassStmt:
+        c.CompileClass(s)
+    }
+}
+
+func (c *Compiler) CompileIf(s IfStmt) {
+    c.CompileExpr(s.cond)
+
+    thenJump := c.EmitJump(OP_JUMP_IF_FALSE)
+    c.Emit(OP_POP)
+    c.CompileStmt(s.then)
+
+    elseJump := c.EmitJump(OP_JUMP)
+    c.PatchJump(thenJump)
+    c.Emit(OP_POP)
+
+    if s.otherwise != nil {
+        c.CompileStmt(s.otherwise)
+    }
+    c.PatchJump(elseJump)
+}
+
+func (c *Compiler) CompileWhile(s WhileStmt) {
+    start := len(c.Chunk().Code)
+    c.CompileExpr(s.cond)
+
+    exitJump := c.EmitJump(OP_JUMP_IF_FALSE)
+    c.Emit(OP_POP)
+    c.CompileStmt(s.body)
+    c.SetLine(s.keyword)
+    c.EmitLoop(start)
+
+    c.PatchJump(exitJump)
+    c.Emit(OP_POP)
+}
+
+// compile the function body into a new function object, and emit the
+// closure instruction which creates it at runtime
+func (c *Compiler) CompileFunction(s *FunStmt, typ int) {
+    c.BeginFunction(typ, s.name.Lexeme)
+    c.BeginScope()
+
+    c.current.function.arity = len(s.params)
+    for _, param := range(s.params) {
+        c.AddLocal(param.Lexeme)
+    }
+
+    for _, stmt := range(s.body) {
+        c.CompileStmt(stmt)
+    }
+
+    // no need to end the scope, the frame is discarded by return
+    fn, upvalues := c.EndFunction()
+
+    c.SetLine(s.name)
+    c.EmitShort(OP_CLOSURE, c.MakeConstant(fn))
+    for _, upvalue := range(upvalues) {
+        isLocal := byte(0)
+        if upvalue.isLocal {
+            isLocal = 1
+        }
+        c.Emit(isLocal, upvalue.index)
+    }
+}
+
+func (c *Compiler) CompileClass(s ClassStmt) {
+    c.SetLine(s.name)
+    nameIdx := c.MakeConstant(StringType{v: s.name.Lexeme})
+
+    c.EmitShort(OP_CLASS, nameIdx)
+    c.DefineVariable(s.name.Lexeme)
+
+    cc := &ClassCompiler{enclosing: c.currentClass}
+    c.currentClass = cc
+
+    // the superclass is stored in a local `super` of a new scope,
+    // which is captured by the methods
+    if s.superclass != nil {
+        c.CompileExpr(*s.superclass)
+
+        c.BeginScope()
+        c.AddLocal("super")
+
+        c.NamedVariable(s.name.Lexeme, false)
+        c.SetLocation(s.superclass.token, s.superclass.Span())
+        c.Emit(OP_INHERIT)
+        cc.hasSuperclass = true
+    }
+
+    // keep the class on the stack while binding the methods
+    c.NamedVariable(s.name.Lexeme, false)
+    for _, method := range(s.methods) {
+        typ := FN_METHOD
+        if method.name.Lexeme == "init" {
+            typ = FN_INITIALIZER
+        }
+        c.CompileFunction(method, typ)
+        c.EmitShort(OP_METHOD, c.MakeConstant(StringType{v: method.name.Lexeme}))
+    }
+    c.Emit(OP_POP)
+
+    if cc.hasSuperclass {
+        c.EndScope()
+    }
+
+    c.currentClass = cc.enclosing
+}
+
+var binaryOpCodes = map[string]byte{
+    TK_PLUS: OP_ADD,
+    TK_MINUS: OP_SUBTRACT,
+    TK_STAR: OP_MULTIPLY,
+    TK_SLASH: OP_DIVIDE,
+    TK_TILDE_SLASH: OP_INT_DIVIDE,
+    TK_PERCENT: OP_MODULO,
+    TK_STAR_STAR: OP_POWER,
+    TK_AMPERSAND: OP_BIT_AND,
+    TK_PIPE: OP_BIT_OR,
+    TK_CARET: OP_BIT_XOR,
+    TK_LESS_LESS: OP_SHIFT_LEFT,
+    TK_GREATER_GREATER: OP_SHIFT_RIGHT,
+    TK_EQUAL_EQUAL: OP_EQUAL,
+    TK_BANG_EQUAL: OP_NOT_EQUAL,
+    TK_GREATER: OP_GREATER,
+    TK_GREATER_EQUAL: OP_GREATER_EQUAL,
+    TK_LESS: OP_LESS,
+    TK_LESS_EQUAL: OP_LESS_EQUAL,
+}
+
+func (c *Compiler) CompileExpr(expr Expr) {
+    switch e := expr.(type) {
+    case LiteralExpr:
+        c.SetLine(e.token)
+        switch e.token.Type {
+        case KW_NIL:
+            c.Emit(OP_NIL)
+        case KW_TRUE:
+            c.Emit(OP_TRUE)
+        case KW_FALSE:
+            c.Emit(OP_FALSE)
+        default:
+            // the literal was parsed once by the parser
+            c.EmitConstant(e.value)
+        }
+    case GroupExpr:
+        c.CompileExpr(e.expr)
+    case StringifyExpr:
+        c.CompileExpr(e.expr)
+        c.Emit(OP_STRINGIFY)
+    case UnaryExpr:
+        c.CompileExpr(e.expr)
+        c.SetLocation(e.token, e.Span())
+        switch e.token.Type {
+        case TK_MINUS:
+            c.Emit(OP_NEGATE)
+        case TK_TILDE:
+            c.Emit(OP_BIT_NOT)
+        default:
+            c.Emit(OP_NOT)
+        }
+    case BinaryExpr:
+        c.CompileExpr(e.left)
+        c.CompileExpr(e.right)
+        c.SetLocation(e.optr, e.Span())
+        c.Emit(binaryOpCodes[e.optr.Type])
+    case LogicalExpr:
+        c.CompileLogical(e)
+    case VarExpr:
+        c.SetLine(e.token)
+        c.NamedVariable(e.token.Lexeme, false)
+    case AssignmentExpr:
+        c.CompileExpr(e.expr)
+        c.SetLine(e.token)
+        c.NamedVariable(e.token.Lexeme, true)
+    case CallExpr:
+        c.CompileExpr(e.callee)
+        for _, arg := range(e.args) {
+            c.CompileExpr(arg)
+        }
+        c.SetLocation(e.paren, e.Span())
+        c.Emit(OP_CALL, byte(len(e.args)))
+    case GetExpr:
+        c.CompileExpr(e.object)
+        c.SetLocation(e.name, e.object.Span())
+        c.EmitShort(OP_GET_PROPERTY, c.MakeConstant(StringType{v: e.name.Lexeme}))
+    case SetExpr:
+        c.CompileExpr(e.object)
+        c.CompileExpr(e.value)
+        c.SetLocation(e.name, e.object.Span())
+        c.EmitShort(OP_SET_PROPERTY, c.MakeConstant(StringType{v: e.name.Lexeme}))
+    case ThisExpr:
+        c.SetLine(e.keyword)
+        c.NamedVariable("this", false)
+    case SuperExpr:
+        c.SetLine(e.keyword)
+        c.NamedVariable("this", false)
+        c.NamedVariable("super", false)
+        c.SetLine(e.method)
+        c.EmitShort(OP_GET_SUPER, c.MakeConstant(StringType{v: e.method.Lexeme}))
+    }
+}
+
+// the logical expression jumps over the right operand when the left one
+// decides the result, and leaves the left one on the stack as the result
+func (c *Compiler) CompileLogical(e LogicalExpr) {
+    c.CompileExpr(e.left)
+    c.SetLine(e.optr)
+
+    if e.optr.Type == KW_AND {
+        endJump := c.EmitJump(OP_JUMP_IF_FALSE)
+        c.Emit(OP_POP)
+        c.CompileExpr(e.right)
+        c.PatchJump(endJump)
+        return
+    }
+
+    elseJump := c.EmitJump(OP_JUMP_IF_FALSE)
+    endJump := c.EmitJump(OP_JUMP)
+    c.PatchJump(elseJump)
+    c.Emit(OP_POP)
+    c.CompileExpr(e.right)
+    c.PatchJump(endJump)
+}
@@ -0,0 +1,35 @@
+package lox
+
+import (
+    "context"
+    "errors"
+)
+
+// ErrCancelled is wrapped by the runtime error of a program aborted
+// because its context is done
+var ErrCancelled = errors.New("Execution cancelled.")
+
+// run the statements compiled by Compile until the context is done. the
+// context is checked at every loop iteration and function call, so even
+// a runaway `while (true) {}` is aborted with a runtime error wrapping
+// ErrCancelled
+func (in *Interpreter) RunContext(ctx context.Context, stmts []Stmt) error {
+    previous := in.ctx
+    in.ctx = ctx
+    defer func() {
+        in.ctx = previous
+    }()
+
+    in.ResetUsage()
+    return in.Execute(stmts)
+}
+
+// fail with ErrCancelled if the context of the running program is done
+func (in *Interpreter) CheckCancelled() error {
+    select {
+    case <-in.ctx.Done():
+        return ErrCancelled
+    default:
+        return nil
+    }
+}
@@ -0,0 +1,98 @@
+package lox
+
+import (
+    "fmt"
+    "io"
+)
+
+// print the instructions of the function, followed by the functions
+// nested in its constant pool
+func DisassembleFunction(w io.Writer, fn *FunctionObj) {
+    fn.chunk.Disassemble(w, fn.String())
+
+    for _, constant := range(fn.chunk.Constants) {
+        if nested, ok := constant.(*FunctionObj); ok {
+            DisassembleFunction(w, nested)
+        }
+    }
+}
+
+func (c *Chunk) Disassemble(w io.Writer, name string) {
+    fmt.Fprintf(w, "== %s ==\n", name)
+
+    for offset := 0; offset < len(c.Code); {
+        offset = c.DisassembleInstruction(w, offset)
+    }
+}
+
+// print the instruction at the offset and return the offset of the next one.
+// the format follows clox, for example:
+//   0000    1 OP_CONSTANT         0 '1'
+//   0003    | OP_PRINT
+func (c *Chunk) DisassembleInstruction(w io.Writer, offset int) int {
+    fmt.Fprintf(w, "%04d ", offset)
+    if offset > 0 && c.Lines[offset] == c.Lines[offset - 1] {
+        fmt.Fprintf(w, "   | ")
+    } else {
+        fmt.Fprintf(w, "%4d ", c.Lines[offset])
+    }
+
+    op := c.Code[offset]
+    name, ok := OpNames[op]
+    if !ok {
+        fmt.Fprintf(w, "Unknown opcode %d\n", op)
+        return offset + 1
+    }
+
+    switch op {
+    case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL,
+        OP_GET_PROPERTY, OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD:
+        return c.ConstantInstruction(w, name, offset)
+    case OP_GET_LOCAL, OP_SET_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL:
+        return c.ByteInstruction(w, name, offset)
+    case OP_JUMP, OP_JUMP_IF_FALSE:
+        return c.JumpInstruction(w, name, 1, offset)
+    case OP_LOOP:
+        return c.JumpInstruction(w, name, -1, offset)
+    case OP_CLOSURE:
+        return c.ClosureInstruction(w, name, offset)
+    }
+
+    fmt.Fprintf(w, "%s\n", name)
+    return offset + 1
+}
+
+func (c *Chunk) ConstantInstruction(w io.Writer, name string, offset int) int {
+    idx := c.ReadShort(offset + 1)
+    fmt.Fprintf(w, "%-16s %4d '%s'\n", name, idx, c.Constants[idx])
+    return offset + 3
+}
+
+func (c *Chunk) ByteInstruction(w io.Writer, name string, offset int) int {
+    fmt.Fprintf(w, "%-16s %4d\n", name, c.Code[offset + 1])
+    return offset + 2
+}
+
+func (c *Chunk) JumpInstruction(w io.Writer, name string, sign int, offset int) int {
+    jump := c.ReadShort(offset + 1)
+    fmt.Fprintf(w, "%-16s %4d -> %d\n", name, offset, offset + 3 + sign * jump)
+    return offset + 3
+}
+
+func (c *Chunk) ClosureInstruction(w io.Writer, name string, offset int) int {
+    idx := c.ReadShort(offset + 1)
+    fn := c.Constants[idx].(*FunctionObj)
+    fmt.Fprintf(w, "%-16s %4d %s\n", name, idx, fn)
+
+    offset += 3
+    for i := 0; i < fn.upvalueCount; i++ {
+        kind := "upvalue"
+        if c.Code[offset] == 1 {
+            kind = "local"
+        }
+        fmt.Fprintf(w, "%04d      |                     %s %d\n", offset, kind, c.Code[offset + 1])
+        offset += 2
+    }
+
+    return offset
+}
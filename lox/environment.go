@@ -0,0 +1,76 @@
+package lox
+
+import (
+    "fmt"
+)
+
+// Environment stores the variables of one lexical scope. the enclosing
+// environment is consulted when a name can't be found in the current scope,
+// the chain ends at the global environment whose enclosing is nil.
+type Environment struct {
+    values map[string]ValueType
+    enclosing *Environment
+}
+
+func NewEnvironment(enclosing *Environment) *Environment {
+    return &Environment {
+        values: make(map[string]ValueType),
+        enclosing: enclosing,
+    }
+}
+
+// define a new variable in current scope. redefinition is allowed, so that
+// `var a = 1; var a = 2;` works at the top level
+func (e *Environment) Define(name string, v ValueType) {
+    e.values[name] = v
+}
+
+func (e *Environment) Get(name *Token) (ValueType, error) {
+    for env := e; env != nil; env = env.enclosing {
+        if v, ok := env.values[name.Lexeme]; ok {
+            return v, nil
+        }
+    }
+
+    return NilValue, fmt.Errorf("Undefined variable '%s'.", name.Lexeme)
+}
+
+func (e *Environment) Assign(name *Token, v ValueType) error {
+    for env := e; env != nil; env = env.enclosing {
+        if _, ok := env.values[name.Lexeme]; ok {
+            env.values[name.Lexeme] = v
+            return nil
+        }
+    }
+
+    return fmt.Errorf("Undefined variable '%s'.", name.Lexeme)
+}
+
+// the scope which is the given distance away along the enclosing chain
+func (e *Environment) Ancestor(distance int) *Environment {
+    env := e
+    for i := 0; i < distance; i++ {
+        env = env.enclosing
+    }
+
+    return env
+}
+
+// get the variable resolved by the resolver, no need to walk the whole chain
+func (e *Environment) GetAt(distance int, name string) ValueType {
+    return e.Ancestor(distance).values[name]
+}
+
+func (e *Environment) AssignAt(distance int, name string, v ValueType) {
+    e.Ancestor(distance).values[name] = v
+}
+
+// look up the variable with the depth recorded by the resolver,
+// or fall back to the global scope
+func (in *Interpreter) LookUpVariable(name *Token) (ValueType, error) {
+    if distance, ok := in.locals[name]; ok {
+        return in.environment.GetAt(distance, name.Lexeme), nil
+    }
+
+    return in.globals.Get(name)
+}
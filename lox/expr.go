@@ -0,0 +1,1122 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+//	"reflect"
+)
+
+type Expr interface {
+    // the String method is used to print the expression recursively.
+    // for example: (*(+ 1 2) 3)
+    String() string
+
+    // the Eval method is used to evaluate the result of expression recursively.
+    // in other implementations, maybe use the visitor pattern of AST
+    Eval(in *Interpreter) (ValueType, error)
+
+    // the Span method returns the source range of the expression
+    Span() Span
+}
+
+// Variable expression. for example: a + 123
+type VarExpr struct {
+    token *Token
+}
+
+func (e VarExpr) String() string {
+    return fmt.Sprintf("(var %s)", e.token.Lexeme)
+}
+
+func (e VarExpr) Span() Span {
+    return e.token.Span()
+}
+
+func (e VarExpr) Eval(in *Interpreter) (ValueType, error) {
+    v, err := in.LookUpVariable(e.token)
+    return v, Locate(err, e.token, e.Span())
+}
+
+// Assignment expression. for example: a = 123
+type AssignmentExpr struct {
+    token *Token
+    expr Expr
+}
+
+func (e AssignmentExpr) String() string {
+    return fmt.Sprintf("(= %s %s)", e.token.Lexeme, e.expr.String())
+}
+
+func (e AssignmentExpr) Span() Span {
+    return e.token.Span().Merge(e.expr.Span())
+}
+
+func (e AssignmentExpr) Eval(in *Interpreter) (ValueType, error) {
+    v, err := e.expr.Eval(in)
+    if err != nil {
+        return NilValue, err
+    }
+
+    if distance, ok := in.locals[e.token]; ok {
+        in.environment.AssignAt(distance, e.token.Lexeme, v)
+    } else if err = in.globals.Assign(e.token, v); err != nil {
+        return NilValue, Locate(err, e.token, e.token.Span())
+    }
+
+    return v, nil
+}
+
+// Literal expression. for example: true, false, nil, 123, "abc"
+type LiteralExpr struct {
+    token *Token
+
+    // the value of the token, parsed once rather than at every evaluation
+    value ValueType
+}
+
+func NewLiteralExpr(token *Token) LiteralExpr {
+    v, err := token.Literal()
+    if err != nil {
+        v = NilValue
+    }
+
+    return LiteralExpr{token: token, value: v}
+}
+
+func (e LiteralExpr) String() string {
+    switch e.token.Type {
+    case TK_NUMBER, TK_STRING, TK_INTERPOLATION:
+        return e.token.LiteralString()
+    default:
+        return e.token.Lexeme
+    }
+}
+
+func (e LiteralExpr) Span() Span {
+    return e.token.Span()
+}
+
+func (e LiteralExpr) Eval(in *Interpreter) (ValueType, error) {
+    return e.value, nil
+}
+
+// Group expression. for example: ("abc"), (1+2)
+type GroupExpr struct {
+    expr Expr
+    lparen *Token
+    rparen *Token
+}
+
+func (e GroupExpr) String() string {
+    return fmt.Sprintf("(group %s)", e.expr)
+}
+
+func (e GroupExpr) Span() Span {
+    return e.lparen.Span().Merge(e.rparen.Span())
+}
+
+func (e GroupExpr) Eval(in *Interpreter) (ValueType, error) {
+    return e.expr.Eval(in)
+}
+
+// Stringify expression, the value converted to the string printed for it.
+// the string interpolations are lowered into the concatenation of them:
+// "a ${b} c" is "a " + (str b) + " c"
+type StringifyExpr struct {
+    expr Expr
+}
+
+func (e StringifyExpr) String() string {
+    return fmt.Sprintf("(str %s)", e.expr)
+}
+
+func (e StringifyExpr) Span() Span {
+    return e.expr.Span()
+}
+
+func (e StringifyExpr) Eval(in *Interpreter) (ValueType, error) {
+    v, err := e.expr.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    return Stringify(v), nil
+}
+
+func Stringify(v ValueType) ValueType {
+    if s, ok := v.(StringType); ok {
+        return s
+    }
+
+    return StringType{v: v.String()}
+}
+
+// Unary expression. for example: -1, !a==b
+type UnaryExpr struct {
+    expr Expr
+    token *Token
+}
+
+func (e UnaryExpr) String() string {
+    return fmt.Sprintf("(%s %s)", e.token.Lexeme, e.expr)
+}
+
+func (e UnaryExpr) Span() Span {
+    return e.token.Span().Merge(e.expr.Span())
+}
+
+func (e UnaryExpr) Eval(in *Interpreter) (ValueType, error) {
+    val, err := e.expr.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    res, err := EvalUnary(e.token, val)
+    return res, Locate(err, e.token, e.Span())
+}
+
+// the unary operation shared by the interpreter and the virtual machine
+func EvalUnary(optr *Token, val ValueType) (ValueType, error) {
+    switch(optr.Type) {
+    case TK_MINUS:
+        switch v := val.(type) {
+        case NumberType:
+            return NumberType{v: -v.v}, nil
+        case IntType:
+            if n, ok := NegInt(v.v); ok {
+                return IntType{v: n}, nil
+            }
+            return nil, ErrIntegerOverflow
+        case BigIntType:
+            return BigIntType{v: new(big.Int).Neg(v.v)}, nil
+        case DecimalType:
+            return DecimalType{v: new(big.Rat).Neg(v.v)}, nil
+        }
+        return nil, NewOperandError(optr, "Operand must be a number.", val)
+    case TK_TILDE:
+        switch v := val.(type) {
+        case BigIntType:
+            return BigIntType{v: new(big.Int).Not(v.v)}, nil
+        case IntType, NumberType:
+            if n, ok := AsInt(v); ok {
+                return IntType{v: ^n}, nil
+            }
+        }
+        return nil, NewOperandError(optr, "Operand must be an integer.", val)
+    case TK_BANG:
+        return BoolType{v:!IsTruthy(val)}, nil
+    }
+
+    return nil, fmt.Errorf("Unknown unary operator: %s", optr.Lexeme)
+}
+
+func IsTruthy(val ValueType) bool {
+    return val.IsTrue()
+}
+
+// Logical expression. for example: a or b, a and b
+// the right operand is evaluated only when the left one can't decide the result
+type LogicalExpr struct {
+    left Expr
+    right Expr
+    optr *Token
+    span Span
+}
+
+// the span of the chained expressions is merged once when they're parsed,
+// rather than along the whole chain each time it's needed
+func NewLogicalExpr(left Expr, optr *Token, right Expr) LogicalExpr {
+    return LogicalExpr{left: left, right: right, optr: optr, span: left.Span().Merge(right.Span())}
+}
+
+func (e LogicalExpr) String() string {
+    return fmt.Sprintf("(%s %s %s)", e.optr.Lexeme, e.left, e.right)
+}
+
+func (e LogicalExpr) Span() Span {
+    return e.span
+}
+
+func (e LogicalExpr) Eval(in *Interpreter) (ValueType, error) {
+    lhs, err := e.left.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    // return the operand itself rather than a coerced bool
+    if e.optr.Type == KW_OR {
+        if IsTruthy(lhs) {
+            return lhs, nil
+        }
+    } else {
+        if !IsTruthy(lhs) {
+            return lhs, nil
+        }
+    }
+
+    return e.right.Eval(in)
+}
+
+// Call expression. for example: f(1, 2), f()()
+type CallExpr struct {
+    callee Expr
+    paren *Token // the closing parenthesis, used to locate errors
+    args []Expr
+    span Span
+}
+
+func NewCallExpr(callee Expr, paren *Token, args []Expr) CallExpr {
+    return CallExpr{callee: callee, paren: paren, args: args, span: callee.Span().Merge(paren.Span())}
+}
+
+func (e CallExpr) String() string {
+    var sb strings.Builder
+    sb.WriteString("(call ")
+    sb.WriteString(e.callee.String())
+    for _, arg := range(e.args) {
+        sb.WriteString(" ")
+        sb.WriteString(arg.String())
+    }
+    sb.WriteString(")")
+
+    return sb.String()
+}
+
+func (e CallExpr) Span() Span {
+    return e.span
+}
+
+func (e CallExpr) Eval(in *Interpreter) (ValueType, error) {
+    callee, err := e.callee.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    args := make([]ValueType, 0, len(e.args))
+    for _, arg := range(e.args) {
+        v, err := arg.Eval(in)
+        if err != nil {
+            return nil, err
+        }
+        args = append(args, v)
+    }
+
+    fn, ok := callee.(Callable)
+    if !ok {
+        return nil, Locate(fmt.Errorf("Can only call functions and classes."), e.paren, e.Span())
+    }
+
+    if len(args) != fn.Arity() {
+        return nil, Locate(fmt.Errorf("Expected %d arguments but got %d.", fn.Arity(), len(args)), e.paren, e.Span())
+    }
+
+    if err := in.Step(); err != nil {
+        return nil, Locate(err, e.paren, e.Span())
+    }
+    if in.depth >= in.MaxCallDepth() {
+        return nil, Locate(ErrStackOverflow, e.paren, e.Span())
+    }
+
+    // the error raised in the callee continues to unwind from the call site,
+    // and the errors of native functions are located at the call
+    in.depth++
+    res, err := fn.Call(in, args)
+    in.depth--
+    if rt, ok := err.(*RuntimeError); ok {
+        rt.SetCallSite(e.paren)
+        return nil, rt
+    }
+
+    return res, Locate(err, e.paren, e.Span())
+}
+
+// Get expression. for example: a.b
+type GetExpr struct {
+    object Expr
+    name *Token
+    span Span
+}
+
+func NewGetExpr(object Expr, name *Token) GetExpr {
+    return GetExpr{object: object, name: name, span: object.Span().Merge(name.Span())}
+}
+
+func (e GetExpr) String() string {
+    return fmt.Sprintf("(. %s %s)", e.object, e.name.Lexeme)
+}
+
+func (e GetExpr) Span() Span {
+    return e.span
+}
+
+func (e GetExpr) Eval(in *Interpreter) (ValueType, error) {
+    object, err := e.object.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    if instance, ok := object.(*InstanceType); ok {
+        v, err := instance.Get(e.name)
+        return v, Locate(err, e.name, e.name.Span())
+    }
+
+    return nil, Locate(fmt.Errorf("Only instances have properties."), e.name, e.object.Span())
+}
+
+// Set expression. for example: a.b = 1
+type SetExpr struct {
+    object Expr
+    name *Token
+    value Expr
+}
+
+func (e SetExpr) String() string {
+    return fmt.Sprintf("(= (. %s %s) %s)", e.object, e.name.Lexeme, e.value)
+}
+
+func (e SetExpr) Span() Span {
+    return e.object.Span().Merge(e.value.Span())
+}
+
+func (e SetExpr) Eval(in *Interpreter) (ValueType, error) {
+    object, err := e.object.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    instance, ok := object.(*InstanceType)
+    if !ok {
+        return nil, Locate(fmt.Errorf("Only instances have fields."), e.name, e.object.Span())
+    }
+
+    v, err := e.value.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    if _, ok := instance.fields[e.name.Lexeme]; !ok {
+        if err := in.Allocate(fieldSize); err != nil {
+            return nil, Locate(err, e.name, e.Span())
+        }
+    }
+
+    instance.Set(e.name, v)
+    return v, nil
+}
+
+// This expression. it's bound to the instance when the method is accessed
+type ThisExpr struct {
+    keyword *Token
+}
+
+func (e ThisExpr) String() string {
+    return "this"
+}
+
+func (e ThisExpr) Span() Span {
+    return e.keyword.Span()
+}
+
+func (e ThisExpr) Eval(in *Interpreter) (ValueType, error) {
+    return in.LookUpVariable(e.keyword)
+}
+
+// Super expression. for example: super.init()
+type SuperExpr struct {
+    keyword *Token
+    method *Token
+}
+
+func (e SuperExpr) String() string {
+    return fmt.Sprintf("(super %s)", e.method.Lexeme)
+}
+
+func (e SuperExpr) Span() Span {
+    return e.keyword.Span().Merge(e.method.Span())
+}
+
+func (e SuperExpr) Eval(in *Interpreter) (ValueType, error) {
+    // the `super` scope encloses the `this` scope of the bound method
+    distance := in.locals[e.keyword]
+    superclass := in.environment.GetAt(distance, "super").(*ClassType)
+    instance := in.environment.GetAt(distance - 1, "this").(*InstanceType)
+
+    method := superclass.FindMethod(e.method.Lexeme)
+    if method == nil {
+        return nil, Locate(fmt.Errorf("Undefined property '%s'.", e.method.Lexeme), e.method, e.method.Span())
+    }
+
+    return method.Bind(instance), nil
+}
+
+// Binary expression. for example: 1+2, 3*4
+type BinaryExpr struct {
+    left Expr
+    right Expr
+    optr *Token
+    span Span
+}
+
+func NewBinaryExpr(left Expr, optr *Token, right Expr) BinaryExpr {
+    return BinaryExpr{left: left, right: right, optr: optr, span: left.Span().Merge(right.Span())}
+}
+
+func (e BinaryExpr) String() string {
+    return fmt.Sprintf("(%s %s %s)", e.optr.Lexeme, e.left, e.right)
+}
+
+func (e BinaryExpr) Span() Span {
+    return e.span
+}
+
+func (e BinaryExpr) Eval(in *Interpreter) (ValueType, error) {
+    lhs, err := e.left.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    rhs, err := e.right.Eval(in)
+    if err != nil {
+        return nil, err
+    }
+
+    res, err := EvalBinary(e.optr, lhs, rhs)
+    if s, ok := res.(StringType); ok && err == nil {
+        err = in.Allocate(len(s.v))
+    }
+
+    return res, Locate(err, e.optr, e.Span())
+}
+
+// the binary operation shared by the interpreter and the virtual machine
+func EvalBinary(optr *Token, lhs, rhs ValueType) (ValueType, error) {
+    res, err := EvalOperator(optr, lhs, rhs)
+    if err == ErrUnmatched {
+        return nil, NewOperandError(optr, OperandMessages[optr.Type], lhs, rhs)
+    }
+
+    return res, err
+}
+
+// the error messages of the binary operators applied to wrong operands
+var OperandMessages = map[string]string{
+    TK_PLUS: "Operands must be two numbers or two strings.",
+    TK_MINUS: "Operands must be numbers.",
+    TK_STAR: "Operands must be numbers.",
+    TK_SLASH: "Operands must be numbers.",
+    TK_TILDE_SLASH: "Operands must be numbers.",
+    TK_PERCENT: "Operands must be numbers.",
+    TK_STAR_STAR: "Operands must be numbers.",
+    TK_AMPERSAND: "Operands must be integers.",
+    TK_PIPE: "Operands must be integers.",
+    TK_CARET: "Operands must be integers.",
+    TK_LESS_LESS: "Operands must be integers.",
+    TK_GREATER_GREATER: "Operands must be integers.",
+    TK_LESS: "Operands must be two numbers or two strings.",
+    TK_LESS_EQUAL: "Operands must be two numbers or two strings.",
+    TK_GREATER: "Operands must be two numbers or two strings.",
+    TK_GREATER_EQUAL: "Operands must be two numbers or two strings.",
+    TK_EQUAL_EQUAL: "Operands can't be compared.",
+    TK_BANG_EQUAL: "Operands can't be compared.",
+}
+
+func EvalOperator(optr *Token, lhs, rhs ValueType) (ValueType, error) {
+    if lhs.Type() != rhs.Type() {
+        // the equality mixing the numbers fails too, rather than being
+        // false for the values which are equal after a conversion
+        lhs, rhs = Promote(lhs, rhs)
+        if err := CheckExactMix(lhs, rhs); err != nil {
+            return nil, err
+        }
+    }
+
+    switch (optr.Type) {
+    case TK_PLUS:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalPlus[IntType],
+            EvalPlus[NumberType],
+            EvalPlus[BigIntType],
+            EvalPlus[DecimalType],
+            EvalPlus[StringType],
+        )
+    case TK_MINUS:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalMinus[IntType],
+            EvalMinus[NumberType],
+            EvalMinus[BigIntType],
+            EvalMinus[DecimalType],
+        )
+    case TK_STAR:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalMul[IntType],
+            EvalMul[NumberType],
+            EvalMul[BigIntType],
+            EvalMul[DecimalType],
+        )
+    case TK_SLASH:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalDiv[IntType],
+            EvalDiv[NumberType],
+            EvalDiv[BigIntType],
+            EvalDiv[DecimalType],
+        )
+    case TK_TILDE_SLASH:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalIntDiv[IntType],
+            EvalIntDiv[NumberType],
+            EvalIntDiv[BigIntType],
+            EvalIntDiv[DecimalType],
+        )
+    case TK_PERCENT:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalMod[IntType],
+            EvalMod[NumberType],
+            EvalMod[BigIntType],
+            EvalMod[DecimalType],
+        )
+    case TK_STAR_STAR:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalPower[IntType],
+            EvalPower[NumberType],
+            EvalPower[BigIntType],
+            EvalPower[DecimalType],
+        )
+    case TK_AMPERSAND, TK_PIPE, TK_CARET, TK_LESS_LESS, TK_GREATER_GREATER:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalBitwise[IntType](optr.Type),
+            EvalBitwise[NumberType](optr.Type),
+            EvalBitwise[BigIntType](optr.Type),
+        )
+    case TK_BANG_EQUAL:
+        if lhs.Type() != rhs.Type() {
+            return TrueValue, nil
+        }
+
+        if IsReference(lhs) {
+            return BoolType{v: lhs != rhs}, nil
+        }
+
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalBangEqual[NilType],
+            EvalBangEqual[BoolType],
+            EvalBangEqual[StringType],
+            EvalBangEqual[IntType],
+            EvalBangEqual[NumberType],
+            EvalBangEqual[BigIntType],
+            EvalBangEqual[DecimalType],
+        )
+    case TK_EQUAL_EQUAL:
+        if lhs.Type() != rhs.Type() {
+            return FalseValue, nil
+        }
+
+        if IsReference(lhs) {
+            return BoolType{v: lhs == rhs}, nil
+        }
+
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalEqualEqual[NilType],
+            EvalEqualEqual[BoolType],
+            EvalEqualEqual[StringType],
+            EvalEqualEqual[IntType],
+            EvalEqualEqual[NumberType],
+            EvalEqualEqual[BigIntType],
+            EvalEqualEqual[DecimalType],
+        )
+    case TK_LESS:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalLess[IntType],
+            EvalLess[NumberType],
+            EvalLess[BigIntType],
+            EvalLess[DecimalType],
+            EvalLess[StringType],
+        )
+    case TK_LESS_EQUAL:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalLessEqual[IntType],
+            EvalLessEqual[NumberType],
+            EvalLessEqual[BigIntType],
+            EvalLessEqual[DecimalType],
+            EvalLessEqual[StringType],
+        )
+    case TK_GREATER:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalGreater[IntType],
+            EvalGreater[NumberType],
+            EvalGreater[BigIntType],
+            EvalGreater[DecimalType],
+            EvalGreater[StringType],
+        )
+    case TK_GREATER_EQUAL:
+        return EvalIfMatch(
+            lhs,
+            rhs,
+            EvalGreaterEqual[IntType],
+            EvalGreaterEqual[NumberType],
+            EvalGreaterEqual[BigIntType],
+            EvalGreaterEqual[DecimalType],
+            EvalGreaterEqual[StringType],
+        )
+    }
+
+    return nil, fmt.Errorf("Unknown binary operator: %s", optr.Lexeme)
+}
+
+
+// ErrUnmatched means none of the functors accepts the types of operands
+var ErrUnmatched = fmt.Errorf("Unmatch functors")
+
+// try the functors in order, the first one accepting the types of operands
+// gives the result or the error of the operation
+func EvalIfMatch(lhs, rhs ValueType, functors ...func(ValueType, ValueType)(ValueType, error)) (ValueType, error) {
+    for _, functor := range(functors) {
+        res, err := functor(lhs, rhs)
+        if err != ErrUnmatched {
+            return res, err
+        }
+    }
+
+    return NilValue, ErrUnmatched
+}
+
+// an operand is promoted to the type of the other one when that one is
+// wider: int to number, and int to bigint to decimal
+func Promote(lhs, rhs ValueType) (ValueType, ValueType) {
+    if v, ok := promoteTo(lhs, rhs); ok {
+        return v, rhs
+    }
+    if v, ok := promoteTo(rhs, lhs); ok {
+        return lhs, v
+    }
+
+    return lhs, rhs
+}
+
+func promoteTo(v, other ValueType) (ValueType, bool) {
+    switch t := v.(type) {
+    case IntType:
+        switch other.(type) {
+        case NumberType:
+            return t.Float(), true
+        case BigIntType:
+            return t.Big(), true
+        case DecimalType:
+            return t.Decimal(), true
+        }
+    case BigIntType:
+        if _, ok := other.(DecimalType); ok {
+            return t.Decimal(), true
+        }
+    }
+
+    return v, false
+}
+
+
+type GenericType interface {
+    NilType | NumberType | IntType | BigIntType | DecimalType | StringType | BoolType
+}
+
+
+type  ComparableType interface {
+    ArithmeticType | StringType
+}
+
+
+type ArithmeticType interface {
+    NumberType | IntType | BigIntType | DecimalType
+}
+
+
+func EvalPlus[T ArithmeticType | StringType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+        switch v1.Type() {
+        case VT_Number:
+            return NumberType{v: v1.(NumberType).v + v2.(NumberType).v}, nil
+        case VT_Int:
+            return checkedInt(AddInt(v1.(IntType).v, v2.(IntType).v))
+        case VT_BigInt:
+            return BigIntType{v: new(big.Int).Add(v1.(BigIntType).v, v2.(BigIntType).v)}, nil
+        case VT_Decimal:
+            return DecimalType{v: new(big.Rat).Add(v1.(DecimalType).v, v2.(DecimalType).v)}, nil
+        case VT_String:
+            return StringType{v: v1.(StringType).v + v2.(StringType).v}, nil
+       }
+
+        return NilValue, nil
+    })
+}
+
+
+func EvalMinus[T ArithmeticType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+        switch v1.Type() {
+        case VT_Int:
+            return checkedInt(SubInt(v1.(IntType).v, v2.(IntType).v))
+        case VT_BigInt:
+            return BigIntType{v: new(big.Int).Sub(v1.(BigIntType).v, v2.(BigIntType).v)}, nil
+        case VT_Decimal:
+            return DecimalType{v: new(big.Rat).Sub(v1.(DecimalType).v, v2.(DecimalType).v)}, nil
+        }
+        return NumberType{v: v1.(NumberType).v - v2.(NumberType).v}, nil
+    })
+}
+
+
+func EvalMul[T ArithmeticType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+        switch v1.Type() {
+        case VT_Int:
+            return checkedInt(MulInt(v1.(IntType).v, v2.(IntType).v))
+        case VT_BigInt:
+            return BigIntType{v: new(big.Int).Mul(v1.(BigIntType).v, v2.(BigIntType).v)}, nil
+        case VT_Decimal:
+            return DecimalType{v: new(big.Rat).Mul(v1.(DecimalType).v, v2.(DecimalType).v)}, nil
+        }
+        return NumberType{v: v1.(NumberType).v * v2.(NumberType).v}, nil
+    })
+}
+
+
+// the division of integers is a number, and the one of big integers is a
+// decimal. see EvalIntDiv for an integer
+func EvalDiv[T ArithmeticType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+        switch v1.Type() {
+        case VT_Int:
+            return NumberType{v: float64(v1.(IntType).v) / float64(v2.(IntType).v)}, nil
+        case VT_BigInt:
+            v, err := QuoRat(v1.(BigIntType).Decimal().v, v2.(BigIntType).Decimal().v)
+            return DecimalType{v: v}, err
+        case VT_Decimal:
+            v, err := QuoRat(v1.(DecimalType).v, v2.(DecimalType).v)
+            return DecimalType{v: v}, err
+        }
+        return NumberType{v: v1.(NumberType).v / v2.(NumberType).v}, nil
+    })
+}
+
+
+func EvalIntDiv[T ArithmeticType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+        switch v1.Type() {
+        case VT_Int:
+            v, err := FloorDivInt(v1.(IntType).v, v2.(IntType).v)
+            return IntType{v: v}, err
+        case VT_BigInt:
+            v, err := FloorDivBig(v1.(BigIntType).v, v2.(BigIntType).v)
+            return BigIntType{v: v}, err
+        case VT_Decimal:
+            v, err := FloorDivRat(v1.(DecimalType).v, v2.(DecimalType).v)
+            return DecimalType{v: v}, err
+        }
+        return NumberType{v: FloorDiv(v1.(NumberType).v, v2.(NumberType).v)}, nil
+    })
+}
+
+
+func EvalMod[T ArithmeticType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+        switch v1.Type() {
+        case VT_Int:
+            v, err := FloorModInt(v1.(IntType).v, v2.(IntType).v)
+            return IntType{v: v}, err
+        case VT_BigInt:
+            v, err := FloorModBig(v1.(BigIntType).v, v2.(BigIntType).v)
+            return BigIntType{v: v}, err
+        case VT_Decimal:
+            v, err := FloorModRat(v1.(DecimalType).v, v2.(DecimalType).v)
+            return DecimalType{v: v}, err
+        }
+        return NumberType{v: FloorMod(v1.(NumberType).v, v2.(NumberType).v)}, nil
+    })
+}
+
+
+// the power of integers is an integer, unless the exponent is negative.
+// the power of big integers is a decimal then
+func EvalPower[T ArithmeticType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+        switch v1.Type() {
+        case VT_Int:
+            a, b := v1.(IntType).v, v2.(IntType).v
+            if b < 0 {
+                return NumberType{v: math.Pow(float64(a), float64(b))}, nil
+            }
+            v, err := PowInt(a, b)
+            return IntType{v: v}, err
+        case VT_BigInt:
+            a, b := v1.(BigIntType).v, v2.(BigIntType).v
+            if !b.IsInt64() {
+                return nil, ErrTooLarge
+            }
+            if b.Sign() < 0 {
+                v, err := PowRat(new(big.Rat).SetInt(a), b.Int64())
+                return DecimalType{v: v}, err
+            }
+            v, err := PowBig(a, b.Int64())
+            return BigIntType{v: v}, err
+        case VT_Decimal:
+            a, b := v1.(DecimalType).v, v2.(DecimalType).v
+            if !b.IsInt() {
+                return nil, ErrDecimalExponent
+            }
+            if !b.Num().IsInt64() {
+                return nil, ErrTooLarge
+            }
+            v, err := PowRat(a, b.Num().Int64())
+            return DecimalType{v: v}, err
+        }
+        return NumberType{v: math.Pow(v1.(NumberType).v, v2.(NumberType).v)}, nil
+    })
+}
+
+
+type IntegralType interface {
+    NumberType | IntType | BigIntType
+}
+
+
+// the bitwise operators apply to the integers, and to the numbers which
+// are integral
+func EvalBitwise[T IntegralType](optr string) func(ValueType, ValueType) (ValueType, error) {
+    return func(lhs, rhs ValueType) (ValueType, error) {
+        return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+            if v1.Type() == VT_BigInt {
+                return evalBitwiseBig(optr, v1.(BigIntType).v, v2.(BigIntType).v)
+            }
+
+            a, ok1 := AsInt(v1)
+            b, ok2 := AsInt(v2)
+            if !ok1 || !ok2 {
+                return nil, ErrUnmatched
+            }
+            return evalBitwiseInt(optr, a, b)
+        })
+    }
+}
+
+func evalBitwiseInt(optr string, a, b int64) (ValueType, error) {
+    var v int64
+    var err error
+    switch optr {
+    case TK_AMPERSAND:
+        v = a & b
+    case TK_PIPE:
+        v = a | b
+    case TK_CARET:
+        v = a ^ b
+    case TK_LESS_LESS:
+        v, err = ShiftLeftInt(a, b)
+    case TK_GREATER_GREATER:
+        v, err = ShiftRightInt(a, b)
+    }
+
+    return IntType{v: v}, err
+}
+
+func evalBitwiseBig(optr string, a, b *big.Int) (ValueType, error) {
+    var v *big.Int
+    var err error
+    switch optr {
+    case TK_AMPERSAND:
+        v = new(big.Int).And(a, b)
+    case TK_PIPE:
+        v = new(big.Int).Or(a, b)
+    case TK_CARET:
+        v = new(big.Int).Xor(a, b)
+    case TK_LESS_LESS:
+        v, err = ShiftLeftBig(a, b)
+    case TK_GREATER_GREATER:
+        v, err = ShiftRightBig(a, b)
+    }
+
+    return BigIntType{v: v}, err
+}
+
+
+func checkedInt(v int64, ok bool) (ValueType, error) {
+    if !ok {
+        return nil, ErrIntegerOverflow
+    }
+
+    return IntType{v: v}, nil
+}
+
+
+func EvalLess[T ComparableType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGeneric[T](lhs, rhs, func(v1, v2 ValueType) ValueType {
+        switch v1.Type() {
+        case VT_Number:
+            return BoolType{v: v1.(NumberType).v < v2.(NumberType).v}
+        case VT_Int:
+            return BoolType{v: v1.(IntType).v < v2.(IntType).v}
+        case VT_BigInt:
+            return BoolType{v: v1.(BigIntType).v.Cmp(v2.(BigIntType).v) < 0}
+        case VT_Decimal:
+            return BoolType{v: v1.(DecimalType).v.Cmp(v2.(DecimalType).v) < 0}
+        case VT_String:
+            return BoolType{v: v1.(StringType).v < v2.(StringType).v}
+       }
+
+        return FalseValue
+    })
+}
+
+
+func EvalLessEqual[T ComparableType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGeneric[T](lhs, rhs, func(v1, v2 ValueType) ValueType {
+        switch v1.Type() {
+        case VT_Number:
+            return BoolType{v: v1.(NumberType).v <= v2.(NumberType).v}
+        case VT_Int:
+            return BoolType{v: v1.(IntType).v <= v2.(IntType).v}
+        case VT_BigInt:
+            return BoolType{v: v1.(BigIntType).v.Cmp(v2.(BigIntType).v) <= 0}
+        case VT_Decimal:
+            return BoolType{v: v1.(DecimalType).v.Cmp(v2.(DecimalType).v) <= 0}
+        case VT_String:
+            return BoolType{v: v1.(StringType).v <= v2.(StringType).v}
+       }
+
+        return FalseValue
+    })
+}
+
+
+func EvalGreater[T ComparableType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGeneric[T](lhs, rhs, func(v1, v2 ValueType) ValueType {
+        switch v1.Type() {
+        case VT_Number:
+            return BoolType{v: v1.(NumberType).v > v2.(NumberType).v}
+        case VT_Int:
+            return BoolType{v: v1.(IntType).v > v2.(IntType).v}
+        case VT_BigInt:
+            return BoolType{v: v1.(BigIntType).v.Cmp(v2.(BigIntType).v) > 0}
+        case VT_Decimal:
+            return BoolType{v: v1.(DecimalType).v.Cmp(v2.(DecimalType).v) > 0}
+        case VT_String:
+            return BoolType{v: v1.(StringType).v > v2.(StringType).v}
+       }
+
+        return FalseValue
+    })
+}
+
+func EvalGreaterEqual[T ComparableType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGeneric[T](lhs, rhs, func(v1, v2 ValueType) ValueType {
+        switch v1.Type() {
+        case VT_Number:
+            return BoolType{v: v1.(NumberType).v >= v2.(NumberType).v}
+        case VT_Int:
+            return BoolType{v: v1.(IntType).v >= v2.(IntType).v}
+        case VT_BigInt:
+            return BoolType{v: v1.(BigIntType).v.Cmp(v2.(BigIntType).v) >= 0}
+        case VT_Decimal:
+            return BoolType{v: v1.(DecimalType).v.Cmp(v2.(DecimalType).v) >= 0}
+        case VT_String:
+            return BoolType{v: v1.(StringType).v >= v2.(StringType).v}
+       }
+
+        return FalseValue
+    })
+}
+
+
+func EvalEqualEqual[T GenericType](lhs, rhs ValueType) (ValueType, error) {
+    return EvalGeneric[T](lhs, rhs, func(v1, v2 ValueType) ValueType {
+        switch v1.Type() {
+        case VT_Nil:
+            return FalseValue
+        case VT_Bool:
+            return BoolType{v: v1.(BoolType).v == v2.(BoolType).v}
+        case VT_Number:
+            return BoolType{v: v1.(NumberType).v == v2.(NumberType).v}
+        case VT_Int:
+            return BoolType{v: v1.(IntType).v == v2.(IntType).v}
+        case VT_BigInt:
+            return BoolType{v: v1.(BigIntType).v.Cmp(v2.(BigIntType).v) == 0}
+        case VT_Decimal:
+            return BoolType{v: v1.(DecimalType).v.Cmp(v2.(DecimalType).v) == 0}
+        case VT_String:
+            return BoolType{v: v1.(StringType).v == v2.(StringType).v}
+       }
+
+        return FalseValue
+    })
+}
+
+
+func EvalBangEqual[T GenericType](lhs, rhs ValueType) (ValueType, error) {
+    // Hack: type of T in generic function cannot use T.field or T.method
+    // we have to provide interfaces of T to acquire type name and value
+    return EvalGeneric[T](lhs, rhs, func(v1, v2 ValueType) ValueType {
+        switch v1.Type() {
+        case VT_Nil:
+            return TrueValue
+        case VT_Bool:
+            return BoolType{v: v1.(BoolType).v != v2.(BoolType).v}
+        case VT_Number:
+            return BoolType{v: v1.(NumberType).v != v2.(NumberType).v}
+        case VT_Int:
+            return BoolType{v: v1.(IntType).v != v2.(IntType).v}
+        case VT_BigInt:
+            return BoolType{v: v1.(BigIntType).v.Cmp(v2.(BigIntType).v) != 0}
+        case VT_Decimal:
+            return BoolType{v: v1.(DecimalType).v.Cmp(v2.(DecimalType).v) != 0}
+        case VT_String:
+            return BoolType{v: v1.(StringType).v != v2.(StringType).v}
+       }
+
+        return FalseValue
+    })
+}
+
+// apply the operation when both operands are of type T, otherwise ErrUnmatched
+func EvalGeneric[T GenericType](lhs, rhs ValueType, op func(ValueType, ValueType) ValueType) (ValueType, error){
+    return EvalGenericChecked[T](lhs, rhs, func(v1, v2 ValueType) (ValueType, error) {
+        return op(v1, v2), nil
+    })
+}
+
+// the same as EvalGeneric, for the operations that may fail such as the
+// integer ones overflowing
+func EvalGenericChecked[T GenericType](lhs, rhs ValueType, op func(ValueType, ValueType) (ValueType, error)) (ValueType, error){
+    if _, ok := lhs.(T); !ok {
+        return nil, ErrUnmatched
+    }
+
+    if _, ok := rhs.(T); !ok {
+        return nil, ErrUnmatched
+    }
+
+    return op(lhs, rhs)
+}
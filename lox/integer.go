@@ -0,0 +1,134 @@
+package lox
+
+import (
+    "errors"
+    "math"
+)
+
+// the integer operations fail rather than wrap around, and the division
+// and modulo are floored, so that a == (a ~/ b) * b + a % b
+
+var ErrIntegerOverflow = errors.New("Integer overflow.")
+var ErrDivisionByZero = errors.New("Division by zero.")
+var ErrNegativeShift = errors.New("Negative shift count.")
+
+func AddInt(a, b int64) (int64, bool) {
+    c := a + b
+    return c, (c > a) == (b > 0)
+}
+
+func SubInt(a, b int64) (int64, bool) {
+    c := a - b
+    return c, (c < a) == (b > 0)
+}
+
+func MulInt(a, b int64) (int64, bool) {
+    if a == 0 || b == 0 {
+        return 0, true
+    }
+
+    c := a * b
+    if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) || c / b != a {
+        return c, false
+    }
+
+    return c, true
+}
+
+func NegInt(a int64) (int64, bool) {
+    return -a, a != math.MinInt64
+}
+
+func FloorDivInt(a, b int64) (int64, error) {
+    if b == 0 {
+        return 0, ErrDivisionByZero
+    }
+    if a == math.MinInt64 && b == -1 {
+        return 0, ErrIntegerOverflow
+    }
+
+    q := a / b
+    if a % b != 0 && (a < 0) != (b < 0) {
+        q--
+    }
+
+    return q, nil
+}
+
+func FloorModInt(a, b int64) (int64, error) {
+    if b == 0 {
+        return 0, ErrDivisionByZero
+    }
+
+    r := a % b
+    if r != 0 && (r < 0) != (b < 0) {
+        r += b
+    }
+
+    return r, nil
+}
+
+// the power by squaring, the exponent is not negative
+func PowInt(a, b int64) (int64, error) {
+    result := int64(1)
+    for b > 0 {
+        if b & 1 == 1 {
+            r, ok := MulInt(result, a)
+            if !ok {
+                return 0, ErrIntegerOverflow
+            }
+            result = r
+        }
+
+        // the square overflowing means the result would overflow too
+        if b >>= 1; b > 0 {
+            sq, ok := MulInt(a, a)
+            if !ok {
+                return 0, ErrIntegerOverflow
+            }
+            a = sq
+        }
+    }
+
+    return result, nil
+}
+
+func ShiftLeftInt(a, n int64) (int64, error) {
+    if n < 0 {
+        return 0, ErrNegativeShift
+    }
+    if a == 0 {
+        return 0, nil
+    }
+
+    c := a << n
+    if n >= 64 || c >> n != a {
+        return 0, ErrIntegerOverflow
+    }
+
+    return c, nil
+}
+
+// the shift is arithmetic, the sign is kept
+func ShiftRightInt(a, n int64) (int64, error) {
+    if n < 0 {
+        return 0, ErrNegativeShift
+    }
+
+    return a >> n, nil
+}
+
+// the floored division and modulo of numbers follow the ones of integers,
+// the division by zero is infinite or nan
+func FloorDiv(a, b float64) float64 {
+    return math.Floor(a / b)
+}
+
+func FloorMod(a, b float64) float64 {
+    r := math.Mod(a, b)
+    if r != 0 && (r < 0) != (b < 0) {
+        r += b
+    }
+
+    return r
+}
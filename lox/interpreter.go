@@ -0,0 +1,317 @@
+// Package lox implements the Lox language: a scanner, a parser, a resolver,
+// a tree-walking interpreter and a bytecode virtual machine. an Interpreter
+// keeps its global variables between runs, so that it can be embedded into
+// Go programs:
+//
+//	in := lox.New(lox.WithStdout(&buf))
+//	err := in.RunString(`var greeting = "hello";`)
+//	v, err := in.Eval(`greeting + " world"`)
+package lox
+
+import (
+    "context"
+    "errors"
+    "io"
+    "os"
+    "strings"
+)
+
+type Interpreter struct {
+    globals *Environment
+    environment *Environment
+
+    // the scope depth of every resolved local variable reference, keyed by
+    // the token of the reference. the variables not found here are globals
+    locals map[*Token]int
+
+    stdout io.Writer
+    stderr io.Writer
+    reporter *Reporter
+
+    // the name of the script being run, recorded in the stack frames
+    file string
+    useVM bool
+
+    // the context of the running program, see RunContext
+    ctx context.Context
+
+    // what the natives may touch
+    permissions Permissions
+
+    // the limits of a run, and the usage counted since its start
+    limits Limits
+    steps int64
+    depth int
+    allocated int64
+}
+
+// Option configures an Interpreter created by New
+type Option func(*Interpreter)
+
+// the output of the print statements, os.Stdout by default
+func WithStdout(w io.Writer) Option {
+    return func(in *Interpreter) {
+        in.stdout = w
+    }
+}
+
+// the output of the error reports, os.Stderr by default
+func WithStderr(w io.Writer) Option {
+    return func(in *Interpreter) {
+        in.stderr = w
+    }
+}
+
+// run the programs with the bytecode virtual machine instead of walking the tree
+func WithVM(enabled bool) Option {
+    return func(in *Interpreter) {
+        in.useVM = enabled
+    }
+}
+
+// report errors in the canonical format without source excerpts
+func WithPlainErrors(enabled bool) Option {
+    return func(in *Interpreter) {
+        in.reporter.Plain = enabled
+    }
+}
+
+// the name of the script, recorded in the stack frames of runtime errors
+func WithFile(name string) Option {
+    return func(in *Interpreter) {
+        in.file = name
+    }
+}
+
+func New(opts ...Option) *Interpreter {
+    globals := NewEnvironment(nil)
+
+    in := &Interpreter {
+        globals: globals,
+        environment: globals,
+        locals: make(map[*Token]int),
+        stdout: os.Stdout,
+        stderr: os.Stderr,
+        reporter: NewReporter(os.Stderr),
+        ctx: context.Background(),
+        permissions: DefaultPermissions,
+    }
+
+    for _, opt := range(opts) {
+        opt(in)
+    }
+    in.reporter.Out = in.stderr
+
+    in.DefineNatives()
+
+    return in
+}
+
+// CompileError holds the static errors of a source: the lexical and syntax
+// errors, the ones found by the resolver and by the bytecode compiler
+type CompileError struct {
+    Errors []error
+}
+
+func (e *CompileError) Error() string {
+    msgs := make([]string, len(e.Errors))
+    for i, err := range(e.Errors) {
+        msgs[i] = err.Error()
+    }
+
+    return strings.Join(msgs, "\n")
+}
+
+func (e *CompileError) Unwrap() []error {
+    return e.Errors
+}
+
+// scan, parse and resolve the source. all the static errors are collected
+// into a CompileError
+func (in *Interpreter) Compile(source string) ([]Stmt, error) {
+    scanner := NewScanner(source)
+    tokens := scanner.ScanTokens()
+
+    parser := NewParser(tokens)
+    stmts, _ := parser.Parse()
+
+    // report the syntax errors along with the lexical ones
+    errs := append(scanner.Errors, parser.Errors...)
+    if len(errs) > 0 {
+        return nil, &CompileError{Errors: errs}
+    }
+
+    resolver := NewResolver(in.locals)
+    resolver.Resolve(stmts)
+    if resolver.HasError() {
+        return nil, &CompileError{Errors: resolver.Errors}
+    }
+
+    return stmts, nil
+}
+
+// run the source as a script. the errors are reported to the error output
+// as well as returned, a *CompileError for the static ones, otherwise a
+// *RuntimeError
+func (in *Interpreter) RunString(source string) error {
+    return in.RunStringContext(context.Background(), source)
+}
+
+// run the source as a script until the context is done, see RunContext
+func (in *Interpreter) RunStringContext(ctx context.Context, source string) error {
+    in.reporter.Source = source
+
+    stmts, err := in.Compile(source)
+    if err == nil {
+        err = in.RunContext(ctx, stmts)
+    }
+
+    if err != nil {
+        in.reporter.PrintError(err)
+    }
+
+    return err
+}
+
+// run the resolved statements with the configured backend
+func (in *Interpreter) Execute(stmts []Stmt) error {
+    if !in.useVM {
+        return in.Interpret(stmts)
+    }
+
+    fn, err := NewCompiler().Compile(stmts)
+    if err != nil {
+        return &CompileError{Errors: []error{err}}
+    }
+
+    return NewVM(in).Interpret(fn)
+}
+
+// run the statements of the top-level script
+func (in *Interpreter) Interpret(stmts []Stmt) error {
+    for _, stmt := range(stmts) {
+        if err := stmt.Run(in); err != nil {
+            if rt, ok := err.(*RuntimeError); ok {
+                rt.PushFrame("", in.file)
+            }
+            return err
+        }
+    }
+
+    return nil
+}
+
+// parse the source as a single expression
+func (in *Interpreter) ParseExpression(source string) (Expr, error) {
+    scanner := NewScanner(source)
+    tokens := scanner.ScanTokens()
+
+    parser := NewParser(tokens)
+    expr, err := parser.ParseExpression()
+
+    errs := scanner.Errors
+    if err != nil {
+        errs = append(errs, err)
+    } else if !parser.IsEnd() {
+        errs = append(errs, parser.Error(parser.Peek(), "Expect end of expression."))
+    }
+    if len(errs) > 0 {
+        return nil, &CompileError{Errors: errs}
+    }
+
+    resolver := NewResolver(in.locals)
+    resolver.ResolveExpr(expr)
+    if resolver.HasError() {
+        return nil, &CompileError{Errors: resolver.Errors}
+    }
+
+    return expr, nil
+}
+
+// tell whether the source is a single expression, which Eval accepts
+func (in *Interpreter) IsExpression(source string) bool {
+    scanner := NewScanner(source)
+    tokens := scanner.ScanTokens()
+    if scanner.HasError() {
+        return false
+    }
+
+    parser := NewParser(tokens)
+    _, err := parser.ParseExpression()
+
+    return err == nil && parser.IsEnd()
+}
+
+// evaluate the source as a single expression in the global scope, with
+// the configured backend. the errors are reported the same as RunString does
+func (in *Interpreter) Eval(source string) (ValueType, error) {
+    in.reporter.Source = source
+
+    expr, err := in.ParseExpression(source)
+    if err != nil {
+        in.reporter.PrintError(err)
+        return nil, err
+    }
+
+    in.ResetUsage()
+
+    v, err := in.Evaluate(expr)
+    if err != nil {
+        in.reporter.PrintError(err)
+        return nil, err
+    }
+
+    return v, nil
+}
+
+// evaluate the resolved expression with the configured backend
+func (in *Interpreter) Evaluate(expr Expr) (ValueType, error) {
+    if in.useVM {
+        fn, err := NewCompiler().CompileExpression(expr)
+        if err != nil {
+            return nil, &CompileError{Errors: []error{err}}
+        }
+        return NewVM(in).Evaluate(fn)
+    }
+
+    v, err := expr.Eval(in)
+    if rt, ok := err.(*RuntimeError); ok {
+        rt.PushFrame("", in.file)
+    }
+
+    return v, err
+}
+
+// the value of a global variable
+func (in *Interpreter) Get(name string) (ValueType, error) {
+    return in.globals.Get(&Token{Type: TK_IDENTIFIER, Lexeme: name})
+}
+
+// define a global variable, or overwrite the existing one
+func (in *Interpreter) Set(name string, v ValueType) {
+    in.globals.Define(name, v)
+}
+
+// compile the source to bytecode and print it to the output
+func (in *Interpreter) Disassemble(source string) error {
+    in.reporter.Source = source
+
+    stmts, err := in.Compile(source)
+    if err == nil {
+        var fn *FunctionObj
+        if fn, err = NewCompiler().Compile(stmts); err == nil {
+            DisassembleFunction(in.stdout, fn)
+            return nil
+        }
+        err = &CompileError{Errors: []error{err}}
+    }
+
+    in.reporter.PrintError(err)
+    return err
+}
+
+// tell whether the error is a static one, found before the program runs
+func IsCompileError(err error) bool {
+    var ce *CompileError
+    return errors.As(err, &ce)
+}
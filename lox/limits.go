@@ -0,0 +1,87 @@
+package lox
+
+// the default maximum call depth, deep enough for the usual recursions
+// while the go stack of the tree-walking interpreter stays small
+const DefaultMaxCallDepth = 10000
+
+// the estimated sizes of the objects counted against the memory limit
+const (
+    instanceSize = 64
+    fieldSize = 32
+    closureSize = 48
+)
+
+// Limits bound the resources used by a run of an untrusted program. the
+// zero values mean no limit, except for MaxCallDepth which falls back to
+// DefaultMaxCallDepth. the usage is counted from the start of each run
+type Limits struct {
+    // the number of loop iterations and function calls
+    MaxSteps int64
+
+    // the number of nested function calls
+    MaxCallDepth int
+
+    // the bytes of the concatenated strings, instances, fields and closures
+    // created by the program. the memory is never given back
+    MaxMemory int64
+}
+
+// run the programs within the limits
+func WithLimits(limits Limits) Option {
+    return func(in *Interpreter) {
+        in.limits = limits
+    }
+}
+
+// LimitError is wrapped by the runtime error of a program exceeding one of
+// its limits, so that the host can tell it apart from the bugs of the
+// program itself
+type LimitError struct {
+    Message string
+}
+
+func (e *LimitError) Error() string {
+    return e.Message
+}
+
+var (
+    ErrStepLimit = &LimitError{Message: "Step limit exceeded."}
+    ErrStackOverflow = &LimitError{Message: "Stack overflow."}
+    ErrMemoryLimit = &LimitError{Message: "Memory limit exceeded."}
+)
+
+func (in *Interpreter) MaxCallDepth() int {
+    if in.limits.MaxCallDepth > 0 {
+        return in.limits.MaxCallDepth
+    }
+
+    return DefaultMaxCallDepth
+}
+
+// start counting the usage of a new run
+func (in *Interpreter) ResetUsage() {
+    in.steps = 0
+    in.depth = 0
+    in.allocated = 0
+}
+
+// count a loop iteration or a function call, and check whether the
+// program may go on
+func (in *Interpreter) Step() error {
+    in.steps++
+    if in.limits.MaxSteps > 0 && in.steps > in.limits.MaxSteps {
+        return ErrStepLimit
+    }
+
+    return in.CheckCancelled()
+}
+
+// count the bytes allocated by the program
+func (in *Interpreter) Allocate(size int) error {
+    in.allocated += int64(size)
+    if in.limits.MaxMemory > 0 && in.allocated > in.limits.MaxMemory {
+        return ErrMemoryLimit
+    }
+
+    return nil
+}
@@ -0,0 +1,571 @@
+package lox
+
+import (
+    "fmt"
+    "math"
+    "math/big"
+    "reflect"
+    "runtime"
+    "strconv"
+    "strings"
+)
+
+// the conversions between go values and lox values. lox has no lists or
+// maps, so they are converted to instances:
+//   nil, nil pointers        nil
+//   bool                     bool
+//   ints and uints           int, or number beyond the int range
+//   floats                   number
+//   *big.Int, *big.Rat       bigint, decimal
+//   string                   string
+//   slices and arrays        a List instance, with the length field and
+//                            the get(index) method
+//   maps with string keys    a Map instance, one field per key
+//   structs                  an instance of a class named after the struct,
+//                            one field per exported struct field
+//   funcs                    a native function
+//
+// the struct fields are named after the go fields, unless the `lox` tag
+// gives another name. the fields tagged with `lox:"-"` are skipped:
+//   type User struct {
+//       Name string `lox:"name"`
+//       Password string `lox:"-"`
+//   }
+
+var valueTypeOf = reflect.TypeOf((*ValueType)(nil)).Elem()
+var errorTypeOf = reflect.TypeOf((*error)(nil)).Elem()
+var bigIntTypeOf = reflect.TypeOf((*big.Int)(nil))
+var bigRatTypeOf = reflect.TypeOf((*big.Rat)(nil))
+
+// the pointers, maps and slices being converted by ToValue. a go value
+// referring to itself can't be converted, as the instances are copies
+type visit struct {
+    ptr uintptr
+    typ reflect.Type
+    len int
+}
+
+type visiting map[visit]bool
+
+// mark the value as being converted until leave is called, or fail if it
+// already is, which means it refers to itself
+func (seen visiting) enter(rv reflect.Value) (leave func(), err error) {
+    key := visit{ptr: rv.Pointer(), typ: rv.Type()}
+    if rv.Kind() == reflect.Slice {
+        key.len = rv.Len()
+    }
+    if seen[key] {
+        return nil, fmt.Errorf("lox: cannot convert %s to a value, it refers to itself", rv.Type())
+    }
+
+    seen[key] = true
+    return func() { delete(seen, key) }, nil
+}
+
+// convert the go value to a lox value. the instances are copies, so the
+// changes made by scripts are not seen by the go value, and the values
+// referring to themselves can't be converted
+func ToValue(v any) (ValueType, error) {
+    if v == nil {
+        return NilValue, nil
+    }
+
+    return toValue(reflect.ValueOf(v), visiting{})
+}
+
+func toValue(rv reflect.Value, seen visiting) (ValueType, error) {
+    if rv.Type().Implements(valueTypeOf) {
+        if rv.Kind() == reflect.Interface && rv.IsNil() {
+            return NilValue, nil
+        }
+        return rv.Interface().(ValueType), nil
+    }
+
+    // the big numbers are copied, as the go values may change
+    switch rv.Type() {
+    case bigIntTypeOf:
+        if rv.IsNil() {
+            return NilValue, nil
+        }
+        return NewBigInt(rv.Interface().(*big.Int)), nil
+    case bigRatTypeOf:
+        if rv.IsNil() {
+            return NilValue, nil
+        }
+        return NewDecimal(rv.Interface().(*big.Rat)), nil
+    }
+
+    switch rv.Kind() {
+    case reflect.Bool:
+        return BoolType{v: rv.Bool()}, nil
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return IntType{v: rv.Int()}, nil
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+        // the unsigned integers beyond the int range are rounded to numbers
+        if rv.Uint() > math.MaxInt64 {
+            return NumberType{v: float64(rv.Uint())}, nil
+        }
+        return IntType{v: int64(rv.Uint())}, nil
+    case reflect.Float32, reflect.Float64:
+        return NumberType{v: rv.Float()}, nil
+    case reflect.String:
+        return StringType{v: rv.String()}, nil
+    case reflect.Interface:
+        if rv.IsNil() {
+            return NilValue, nil
+        }
+        return toValue(rv.Elem(), seen)
+    case reflect.Pointer:
+        if rv.IsNil() {
+            return NilValue, nil
+        }
+        leave, err := seen.enter(rv)
+        if err != nil {
+            return nil, err
+        }
+        defer leave()
+        return toValue(rv.Elem(), seen)
+    case reflect.Slice, reflect.Array:
+        return toList(rv, seen)
+    case reflect.Map:
+        return toMap(rv, seen)
+    case reflect.Struct:
+        return toInstance(rv, seen)
+    case reflect.Func:
+        return toNative(rv)
+    }
+
+    return nil, fmt.Errorf("lox: cannot convert %s to a value", rv.Type())
+}
+
+// the elements are kept in the fields named by their index, which the
+// scripts can only read through get()
+func toList(rv reflect.Value, seen visiting) (ValueType, error) {
+    if rv.Kind() == reflect.Slice {
+        if rv.IsNil() {
+            return NilValue, nil
+        }
+        leave, err := seen.enter(rv)
+        if err != nil {
+            return nil, err
+        }
+        defer leave()
+    }
+
+    list := &InstanceType{class: &ClassType{name: "List"}, fields: make(map[string]ValueType)}
+    for i := 0; i < rv.Len(); i++ {
+        v, err := toValue(rv.Index(i), seen)
+        if err != nil {
+            return nil, err
+        }
+        list.fields[strconv.Itoa(i)] = v
+    }
+
+    length := rv.Len()
+    list.fields["length"] = IntType{v: int64(length)}
+    list.fields["get"] = NewNativeFunction("get", 1, func(args []ValueType) (ValueType, error) {
+        index, ok := AsInt(args[0])
+        if !ok {
+            return nil, fmt.Errorf("List index must be an integer.")
+        }
+        if index < 0 || index >= int64(length) {
+            return nil, fmt.Errorf("List index out of range.")
+        }
+        return list.fields[strconv.FormatInt(index, 10)], nil
+    })
+
+    return list, nil
+}
+
+func toMap(rv reflect.Value, seen visiting) (ValueType, error) {
+    if rv.Type().Key().Kind() != reflect.String {
+        return nil, fmt.Errorf("lox: cannot convert %s to a value, the keys must be strings", rv.Type())
+    }
+    if rv.IsNil() {
+        return NilValue, nil
+    }
+    leave, err := seen.enter(rv)
+    if err != nil {
+        return nil, err
+    }
+    defer leave()
+
+    instance := &InstanceType{class: &ClassType{name: "Map"}, fields: make(map[string]ValueType)}
+    iter := rv.MapRange()
+    for iter.Next() {
+        v, err := toValue(iter.Value(), seen)
+        if err != nil {
+            return nil, err
+        }
+        instance.fields[iter.Key().String()] = v
+    }
+
+    return instance, nil
+}
+
+func toInstance(rv reflect.Value, seen visiting) (ValueType, error) {
+    name := rv.Type().Name()
+    if name == "" {
+        name = "Object"
+    }
+
+    instance := &InstanceType{class: &ClassType{name: name}, fields: make(map[string]ValueType)}
+    for _, field := range(reflect.VisibleFields(rv.Type())) {
+        fieldName, ok := loxFieldName(field)
+        if !ok {
+            continue
+        }
+
+        // the fields promoted from a nil embedded pointer are skipped
+        fieldValue, err := rv.FieldByIndexErr(field.Index)
+        if err != nil {
+            continue
+        }
+
+        v, err := toValue(fieldValue, seen)
+        if err != nil {
+            return nil, err
+        }
+        instance.fields[fieldName] = v
+    }
+
+    return instance, nil
+}
+
+// the name of the struct field in lox, false if the field is not converted
+func loxFieldName(field reflect.StructField) (string, bool) {
+    if !field.IsExported() || field.Anonymous {
+        return "", false
+    }
+
+    tag := field.Tag.Get("lox")
+    if tag == "-" {
+        return "", false
+    }
+    if name, _, _ := strings.Cut(tag, ","); name != "" {
+        return name, true
+    }
+
+    return field.Name, true
+}
+
+// the go function is wrapped into a native function. the arguments are
+// converted with FromValue, and the results may be nothing, a value, an
+// error or a value followed by an error
+func toNative(rv reflect.Value) (ValueType, error) {
+    if rv.IsNil() {
+        return NilValue, nil
+    }
+
+    typ := rv.Type()
+    if typ.IsVariadic() {
+        return nil, fmt.Errorf("lox: cannot convert variadic %s to a value", typ)
+    }
+    if typ.NumIn() > MaxArity {
+        return nil, fmt.Errorf("lox: cannot convert %s to a value, it has more than %d parameters", typ, MaxArity)
+    }
+
+    switch {
+    case typ.NumOut() <= 1:
+    case typ.NumOut() == 2 && typ.Out(1) == errorTypeOf:
+    default:
+        return nil, fmt.Errorf("lox: cannot convert %s to a value, it returns too many results", typ)
+    }
+
+    name := "native"
+    if fn := runtime.FuncForPC(rv.Pointer()); fn != nil {
+        name = fn.Name()[strings.LastIndex(fn.Name(), ".") + 1:]
+    }
+
+    return NewNativeFunction(name, typ.NumIn(), func(args []ValueType) (ValueType, error) {
+        in := make([]reflect.Value, len(args))
+        for i, arg := range(args) {
+            in[i] = reflect.New(typ.In(i)).Elem()
+            if err := fromValue(arg, in[i], map[ValueType]bool{}); err != nil {
+                return nil, fmt.Errorf("Invalid argument %d: %v.", i + 1, err)
+            }
+        }
+
+        out := rv.Call(in)
+        if len(out) > 0 && typ.Out(len(out) - 1) == errorTypeOf {
+            if err, _ := out[len(out) - 1].Interface().(error); err != nil {
+                return nil, err
+            }
+            out = out[:len(out) - 1]
+        }
+
+        if len(out) == 0 {
+            return NilValue, nil
+        }
+        return toValue(out[0], visiting{})
+    }), nil
+}
+
+// store the lox value into the go value pointed by target. the numbers
+// stored into integers must be integral and in range
+func FromValue(v ValueType, target any) error {
+    rv := reflect.ValueOf(target)
+    if rv.Kind() != reflect.Pointer || rv.IsNil() {
+        return fmt.Errorf("lox: the target must be a non-nil pointer, not %T", target)
+    }
+
+    if err := fromValue(v, rv.Elem(), map[ValueType]bool{}); err != nil {
+        return fmt.Errorf("lox: %w", err)
+    }
+
+    return nil
+}
+
+// the instances being converted are seen, an instance referring to itself
+// can't be converted as the go values are copies
+func fromValue(v ValueType, rv reflect.Value, seen map[ValueType]bool) error {
+    if v == nil {
+        v = NilValue
+    }
+
+    // the lox values are stored as they are
+    if reflect.TypeOf(v).AssignableTo(rv.Type()) && rv.Kind() != reflect.Interface || rv.Type() == valueTypeOf {
+        rv.Set(reflect.ValueOf(v))
+        return nil
+    }
+
+    if _, ok := v.(NilType); ok {
+        switch rv.Kind() {
+        case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+            rv.Set(reflect.Zero(rv.Type()))
+            return nil
+        }
+        return cannotConvert(v, rv.Type())
+    }
+
+    // the integers are widened to the big numbers, not the other way
+    switch rv.Type() {
+    case bigIntTypeOf:
+        switch n := v.(type) {
+        case IntType:
+            rv.Set(reflect.ValueOf(n.Big().v))
+            return nil
+        case BigIntType:
+            rv.Set(reflect.ValueOf(n.Literal()))
+            return nil
+        }
+        return cannotConvert(v, rv.Type())
+    case bigRatTypeOf:
+        switch n := v.(type) {
+        case IntType:
+            rv.Set(reflect.ValueOf(n.Decimal().v))
+            return nil
+        case BigIntType:
+            rv.Set(reflect.ValueOf(n.Decimal().v))
+            return nil
+        case DecimalType:
+            rv.Set(reflect.ValueOf(n.Literal()))
+            return nil
+        }
+        return cannotConvert(v, rv.Type())
+    }
+
+    switch rv.Kind() {
+    case reflect.Bool:
+        if b, ok := v.(BoolType); ok {
+            rv.SetBool(b.v)
+            return nil
+        }
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        if n, ok := v.(IntType); ok {
+            if rv.OverflowInt(n.v) {
+                return fmt.Errorf("%s overflows %s", n, rv.Type())
+            }
+            rv.SetInt(n.v)
+            return nil
+        }
+        if n, ok := v.(NumberType); ok {
+            if n.v != math.Trunc(n.v) {
+                return notInteger(n, rv.Type())
+            }
+            // 2^63 is the first float out of the range, check it before
+            // the conversion
+            if n.v >= 1 << 63 || n.v < -(1 << 63) || rv.OverflowInt(int64(n.v)) {
+                return fmt.Errorf("%s overflows %s", n, rv.Type())
+            }
+            rv.SetInt(int64(n.v))
+            return nil
+        }
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+        if n, ok := v.(IntType); ok {
+            if n.v < 0 || rv.OverflowUint(uint64(n.v)) {
+                return fmt.Errorf("%s overflows %s", n, rv.Type())
+            }
+            rv.SetUint(uint64(n.v))
+            return nil
+        }
+        if n, ok := v.(NumberType); ok {
+            if n.v != math.Trunc(n.v) {
+                return notInteger(n, rv.Type())
+            }
+            if n.v < 0 || n.v >= 1 << 64 || rv.OverflowUint(uint64(n.v)) {
+                return fmt.Errorf("%s overflows %s", n, rv.Type())
+            }
+            rv.SetUint(uint64(n.v))
+            return nil
+        }
+    case reflect.Float32, reflect.Float64:
+        if n, ok := v.(NumberType); ok {
+            rv.SetFloat(n.v)
+            return nil
+        }
+        if n, ok := v.(IntType); ok {
+            rv.SetFloat(float64(n.v))
+            return nil
+        }
+    case reflect.String:
+        if s, ok := v.(StringType); ok {
+            rv.SetString(s.v)
+            return nil
+        }
+    case reflect.Pointer:
+        elem := reflect.New(rv.Type().Elem())
+        if err := fromValue(v, elem.Elem(), seen); err != nil {
+            return err
+        }
+        rv.Set(elem)
+        return nil
+    case reflect.Interface:
+        if goValue := toGo(v); goValue != nil && reflect.TypeOf(goValue).AssignableTo(rv.Type()) {
+            rv.Set(reflect.ValueOf(goValue))
+            return nil
+        }
+    case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+        if seen[v] {
+            return fmt.Errorf("cannot convert %s to %s, it refers to itself", v.Type(), rv.Type())
+        }
+        seen[v] = true
+        defer delete(seen, v)
+
+        switch rv.Kind() {
+        case reflect.Map:
+            return fromMap(v, rv, seen)
+        case reflect.Struct:
+            return fromInstance(v, rv, seen)
+        }
+        return fromList(v, rv, seen)
+    }
+
+    return cannotConvert(v, rv.Type())
+}
+
+// the go value of a lox value stored into an interface: the primitives
+// are unwrapped, the other values are kept as they are
+func toGo(v ValueType) any {
+    switch t := v.(type) {
+    case BoolType, NumberType, IntType, BigIntType, DecimalType, StringType:
+        return t.Literal()
+    }
+
+    return v
+}
+
+func cannotConvert(v ValueType, typ reflect.Type) error {
+    return fmt.Errorf("cannot convert %s to %s", v.Type(), typ)
+}
+
+func notInteger(n NumberType, typ reflect.Type) error {
+    return fmt.Errorf("cannot convert %s to %s, it's not an integer", n, typ)
+}
+
+// the fields of the instances created by the tree-walking interpreter,
+// the virtual machine or ToValue
+func instanceFields(v ValueType) (map[string]ValueType, bool) {
+    switch t := v.(type) {
+    case *InstanceType:
+        return t.fields, true
+    case *InstanceObj:
+        return t.fields, true
+    }
+
+    return nil, false
+}
+
+// the lists are the instances with a length field and the elements in
+// the fields named by their index, such as the ones made by ToValue
+func fromList(v ValueType, rv reflect.Value, seen map[ValueType]bool) error {
+    fields, ok := instanceFields(v)
+    length, isInt := AsInt(fields["length"])
+    if !ok || !isInt || length < 0 {
+        return cannotConvert(v, rv.Type())
+    }
+
+    n := int(length)
+    if rv.Kind() == reflect.Array {
+        if n != rv.Len() {
+            return fmt.Errorf("cannot convert a list of %d elements to %s", n, rv.Type())
+        }
+    } else {
+        rv.Set(reflect.MakeSlice(rv.Type(), n, n))
+    }
+
+    for i := 0; i < n; i++ {
+        if err := fromValue(fields[strconv.Itoa(i)], rv.Index(i), seen); err != nil {
+            return fmt.Errorf("element %d: %w", i, err)
+        }
+    }
+
+    return nil
+}
+
+func fromMap(v ValueType, rv reflect.Value, seen map[ValueType]bool) error {
+    fields, ok := instanceFields(v)
+    if !ok || rv.Type().Key().Kind() != reflect.String {
+        return cannotConvert(v, rv.Type())
+    }
+
+    m := reflect.MakeMapWithSize(rv.Type(), len(fields))
+    for name, field := range(fields) {
+        elem := reflect.New(rv.Type().Elem()).Elem()
+        if err := fromValue(field, elem, seen); err != nil {
+            return fmt.Errorf("field %s: %w", name, err)
+        }
+        m.SetMapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()), elem)
+    }
+    rv.Set(m)
+
+    return nil
+}
+
+// the struct fields missing from the instance are left unchanged
+func fromInstance(v ValueType, rv reflect.Value, seen map[ValueType]bool) error {
+    fields, ok := instanceFields(v)
+    if !ok {
+        return cannotConvert(v, rv.Type())
+    }
+
+    for _, field := range(reflect.VisibleFields(rv.Type())) {
+        name, ok := loxFieldName(field)
+        if !ok {
+            continue
+        }
+
+        // the embedded pointers are allocated as needed
+        if value, ok := fields[name]; ok {
+            if err := fromValue(value, fieldByIndexAlloc(rv, field.Index), seen); err != nil {
+                return fmt.Errorf("field %s: %w", name, err)
+            }
+        }
+    }
+
+    return nil
+}
+
+func fieldByIndexAlloc(rv reflect.Value, index []int) reflect.Value {
+    for i, x := range(index) {
+        if i > 0 && rv.Kind() == reflect.Pointer {
+            if rv.IsNil() {
+                rv.Set(reflect.New(rv.Type().Elem()))
+            }
+            rv = rv.Elem()
+        }
+        rv = rv.Field(x)
+    }
+
+    return rv
+}
@@ -0,0 +1,63 @@
+package lox
+
+import (
+    "math"
+    "testing"
+)
+
+func TestFromValueInt(t *testing.T) {
+    tests := []struct {
+        v float64
+        want int64
+        fails bool
+    }{
+        {0, 0, false},
+        {-3, -3, false},
+        {1 << 62, 1 << 62, false},
+        {-(1 << 63), math.MinInt64, false},
+        {1 << 63, 0, true},
+        {-(1 << 64), 0, true},
+        {1.5, 0, true},
+    }
+
+    for _, tt := range(tests) {
+        var got int64
+        err := FromValue(NewNumber(tt.v), &got)
+        if tt.fails {
+            if err == nil {
+                t.Errorf("FromValue(%v) = %d, want an error", tt.v, got)
+            }
+            continue
+        }
+        if err != nil || got != tt.want {
+            t.Errorf("FromValue(%v) = %d, %v, want %d", tt.v, got, err, tt.want)
+        }
+    }
+}
+
+func TestFromValueUint(t *testing.T) {
+    tests := []struct {
+        v float64
+        want uint64
+        fails bool
+    }{
+        {0, 0, false},
+        {1 << 63, 1 << 63, false},
+        {1 << 64, 0, true},
+        {-1, 0, true},
+    }
+
+    for _, tt := range(tests) {
+        var got uint64
+        err := FromValue(NewNumber(tt.v), &got)
+        if tt.fails {
+            if err == nil {
+                t.Errorf("FromValue(%v) = %d, want an error", tt.v, got)
+            }
+            continue
+        }
+        if err != nil || got != tt.want {
+            t.Errorf("FromValue(%v) = %d, %v, want %d", tt.v, got, err, tt.want)
+        }
+    }
+}
@@ -0,0 +1,247 @@
+package lox
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "math/big"
+    "os"
+    "os/exec"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// define the native functions in the global scope. each of them checks
+// the permissions before touching the outside world
+func (in *Interpreter) DefineNatives() {
+    in.DefineNative("clock", 0, func(args []ValueType) (ValueType, error) {
+        if err := in.CheckClock(); err != nil {
+            return nil, err
+        }
+
+        return NumberType{v: float64(time.Now().UnixNano()) / float64(time.Second)}, nil
+    })
+
+    // int(x) truncates a number toward zero, or parses a string
+    in.DefineNative("int", 1, func(args []ValueType) (ValueType, error) {
+        switch v := args[0].(type) {
+        case IntType:
+            return v, nil
+        case NumberType:
+            n := math.Trunc(v.v)
+            if math.IsNaN(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+                return nil, fmt.Errorf("Can't convert %s to int.", v)
+            }
+            return IntType{v: int64(n)}, nil
+        case BigIntType:
+            if !v.v.IsInt64() {
+                return nil, fmt.Errorf("Can't convert %s to int.", v)
+            }
+            return IntType{v: v.v.Int64()}, nil
+        case DecimalType:
+            n := TruncRat(v.v)
+            if !n.IsInt64() {
+                return nil, fmt.Errorf("Can't convert %s to int.", v)
+            }
+            return IntType{v: n.Int64()}, nil
+        case StringType:
+            n, err := strconv.ParseInt(strings.TrimSpace(v.v), 0, 64)
+            if err != nil {
+                return nil, fmt.Errorf("Can't convert '%s' to int.", v.v)
+            }
+            return IntType{v: n}, nil
+        }
+
+        return nil, fmt.Errorf("Argument of int() must be a number or a string.")
+    })
+
+    // float(x) converts an integer to a number, or parses a string
+    in.DefineNative("float", 1, func(args []ValueType) (ValueType, error) {
+        switch v := args[0].(type) {
+        case IntType:
+            return v.Float(), nil
+        case NumberType:
+            return v, nil
+        case BigIntType:
+            f, _ := new(big.Float).SetInt(v.v).Float64()
+            return NumberType{v: f}, nil
+        case DecimalType:
+            f, _ := v.v.Float64()
+            return NumberType{v: f}, nil
+        case StringType:
+            n, err := strconv.ParseFloat(strings.TrimSpace(v.v), 64)
+            if err != nil && !isRangeError(err) {
+                return nil, fmt.Errorf("Can't convert '%s' to float.", v.v)
+            }
+            return NumberType{v: n}, nil
+        }
+
+        return nil, fmt.Errorf("Argument of float() must be a number or a string.")
+    })
+
+    // bigint(x) truncates a number toward zero, or parses a string
+    in.DefineNative("bigint", 1, func(args []ValueType) (ValueType, error) {
+        switch v := args[0].(type) {
+        case IntType:
+            return v.Big(), nil
+        case NumberType:
+            n, err := FloatToBig(v.v)
+            if err != nil {
+                return nil, err
+            }
+            return BigIntType{v: n}, nil
+        case BigIntType:
+            return v, nil
+        case DecimalType:
+            return BigIntType{v: TruncRat(v.v)}, nil
+        case StringType:
+            n, ok := new(big.Int).SetString(strings.TrimSpace(v.v), 0)
+            if !ok {
+                return nil, fmt.Errorf("Can't convert '%s' to bigint.", v.v)
+            }
+            return BigIntType{v: n}, nil
+        }
+
+        return nil, fmt.Errorf("Argument of bigint() must be a number or a string.")
+    })
+
+    // decimal(x) converts a number to the decimal it's printed as, or
+    // parses a string
+    in.DefineNative("decimal", 1, func(args []ValueType) (ValueType, error) {
+        switch v := args[0].(type) {
+        case IntType:
+            return v.Decimal(), nil
+        case NumberType:
+            r, err := FloatToDecimal(v.v)
+            if err != nil {
+                return nil, err
+            }
+            return DecimalType{v: r}, nil
+        case BigIntType:
+            return v.Decimal(), nil
+        case DecimalType:
+            return v, nil
+        case StringType:
+            r, ok := new(big.Rat).SetString(strings.TrimSpace(v.v))
+            if !ok {
+                return nil, fmt.Errorf("Can't convert '%s' to decimal.", v.v)
+            }
+            return DecimalType{v: r}, nil
+        }
+
+        return nil, fmt.Errorf("Argument of decimal() must be a number or a string.")
+    })
+
+    // readFile(path) returns the content of the file
+    in.DefineNative("readFile", 1, func(args []ValueType) (ValueType, error) {
+        path, err := StringArg("readFile", args, 0)
+        if err != nil {
+            return nil, err
+        }
+        if err := in.CheckRead(path); err != nil {
+            return nil, err
+        }
+
+        content, err := os.ReadFile(path)
+        if err != nil {
+            return nil, fmt.Errorf("Can't read '%s': %v.", path, unwrapPathError(err))
+        }
+
+        return StringType{v: string(content)}, nil
+    })
+
+    // writeFile(path, text) replaces the content of the file
+    in.DefineNative("writeFile", 2, func(args []ValueType) (ValueType, error) {
+        path, err := StringArg("writeFile", args, 0)
+        if err != nil {
+            return nil, err
+        }
+        text, err := StringArg("writeFile", args, 1)
+        if err != nil {
+            return nil, err
+        }
+        if err := in.CheckWrite(path); err != nil {
+            return nil, err
+        }
+
+        if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+            return nil, fmt.Errorf("Can't write '%s': %v.", path, unwrapPathError(err))
+        }
+
+        return NilValue, nil
+    })
+
+    // getenv(name) returns nil if the variable is not set
+    in.DefineNative("getenv", 1, func(args []ValueType) (ValueType, error) {
+        name, err := StringArg("getenv", args, 0)
+        if err != nil {
+            return nil, err
+        }
+        if err := in.CheckEnv(name); err != nil {
+            return nil, err
+        }
+
+        if v, ok := os.LookupEnv(name); ok {
+            return StringType{v: v}, nil
+        }
+        return NilValue, nil
+    })
+
+    // exec(command) runs the command, split into words without a shell,
+    // and returns its output
+    in.DefineNative("exec", 1, func(args []ValueType) (ValueType, error) {
+        command, err := StringArg("exec", args, 0)
+        if err != nil {
+            return nil, err
+        }
+
+        words := strings.Fields(command)
+        if len(words) == 0 {
+            return nil, fmt.Errorf("Command of exec() can't be empty.")
+        }
+        if err := in.CheckExec(words[0]); err != nil {
+            return nil, err
+        }
+
+        cmd := exec.CommandContext(in.ctx, words[0], words[1:]...)
+        cmd.Stderr = in.stderr
+        out, err := cmd.Output()
+        if err != nil {
+            return nil, fmt.Errorf("Command '%s' failed: %v.", command, err)
+        }
+
+        return StringType{v: string(out)}, nil
+    })
+}
+
+// the string argument of the native function at the index
+func StringArg(fn string, args []ValueType, i int) (string, error) {
+    s, ok := args[i].(StringType)
+    if !ok {
+        return "", fmt.Errorf("Argument %d of %s() must be a string.", i + 1, fn)
+    }
+
+    return s.v, nil
+}
+
+// the cause of the failed file operation, without the path repeated
+func unwrapPathError(err error) error {
+    var pathErr *os.PathError
+    if errors.As(err, &pathErr) {
+        return pathErr.Err
+    }
+
+    return err
+}
+
+// expose a go function to the scripts as a global function. the scripts
+// calling it with another number of arguments fail with a runtime error
+func (in *Interpreter) DefineNative(name string, arity int, fn NativeFn) error {
+    if arity < 0 || arity > MaxArity {
+        return fmt.Errorf("native function %s: arity %d is not between 0 and %d", name, arity, MaxArity)
+    }
+
+    in.globals.Define(name, NewNativeFunction(name, arity, fn))
+    return nil
+}
@@ -0,0 +1,242 @@
+package lox
+
+import (
+    "fmt"
+    "math/big"
+    "strconv"
+    "strings"
+)
+
+// the number literals:
+//   decimal: 42, 3.14, 1e-9, 2.5E+3
+//   hexadecimal, binary and octal: 0x1F, 0b1010, 0o17
+// the digits may be separated by underscores: 1_000_000, 0xFF_FF
+// the suffixes make the arbitrary precision literals:
+//   big integers: 123n, 0xFFn
+//   decimals: 1.10d, 5d, 2.5e-3d
+
+// LiteralError is a malformed number literal. the offsets are relative to
+// the start of the literal
+type LiteralError struct {
+    Start int
+    End int
+    Message string
+}
+
+func (e *LiteralError) Error() string {
+    return e.Message
+}
+
+var numberBases = map[byte]struct {
+    base int
+    name string
+}{
+    'x': {16, "hexadecimal"},
+    'b': {2, "binary"},
+    'o': {8, "octal"},
+}
+
+// the value of the number literal, or a *LiteralError locating what's wrong
+func ParseNumber(lexeme string) (float64, error) {
+    if len(lexeme) >= 2 && lexeme[0] == '0' {
+        if prefix, ok := numberBases[lowerByte(lexeme[1])]; ok {
+            return parseBasedNumber(lexeme, prefix.base, prefix.name)
+        }
+    }
+
+    return parseDecimalNumber(lexeme)
+}
+
+func lowerByte(ch byte) byte {
+    if ch >= 'A' && ch <= 'Z' {
+        return ch + 'a' - 'A'
+    }
+
+    return ch
+}
+
+func isDigitOf(ch byte, base int) bool {
+    switch {
+    case ch >= '0' && ch <= '9':
+        return int(ch - '0') < base
+    case lowerByte(ch) >= 'a' && lowerByte(ch) <= 'f':
+        return int(lowerByte(ch) - 'a') + 10 < base
+    }
+
+    return false
+}
+
+// scan the digits from the offset, the underscores must be between two
+// digits. the offset after the digits is returned
+func scanDigits(lexeme string, i int, base int) (int, error) {
+    start := i
+    for ; i < len(lexeme); i++ {
+        ch := lexeme[i]
+        if ch == '_' {
+            if i == start || i + 1 >= len(lexeme) || !isDigitOf(lexeme[i+1], base) {
+                return i, &LiteralError{Start: i, End: i + 1, Message: "Digit separator '_' must be between digits."}
+            }
+            continue
+        }
+        if !isDigitOf(ch, base) {
+            break
+        }
+    }
+
+    return i, nil
+}
+
+func parseBasedNumber(lexeme string, base int, name string) (float64, error) {
+    end, err := scanDigits(lexeme, 2, base)
+    if err != nil {
+        return 0, err
+    }
+
+    if end == 2 {
+        if end < len(lexeme) && isDigitOf(lexeme[end], 16) {
+            return 0, &LiteralError{Start: end, End: end + 1, Message: fmt.Sprintf("Invalid digit '%c' in %s literal.", lexeme[end], name)}
+        }
+        return 0, &LiteralError{Start: 0, End: 2, Message: fmt.Sprintf("Expect digits after '%s'.", lexeme[:2])}
+    }
+    if end < len(lexeme) {
+        if isDigitOf(lexeme[end], 16) {
+            return 0, &LiteralError{Start: end, End: end + 1, Message: fmt.Sprintf("Invalid digit '%c' in %s literal.", lexeme[end], name)}
+        }
+        if lexeme[end] == '.' {
+            return 0, &LiteralError{Start: end, End: len(lexeme), Message: fmt.Sprintf("A %s literal can't have a fraction.", name)}
+        }
+        return 0, invalidCharacter(lexeme, end)
+    }
+
+    // the value may exceed 64 bits, it's rounded to the nearest float.
+    // ParseLiteral rejects such literals unless they have the n suffix
+    digits := strings.ReplaceAll(lexeme[2:], "_", "")
+    n, _ := new(big.Int).SetString(digits, base)
+    v, _ := new(big.Float).SetInt(n).Float64()
+    return v, nil
+}
+
+func parseDecimalNumber(lexeme string) (float64, error) {
+    i, err := scanDigits(lexeme, 0, 10)
+    if err != nil {
+        return 0, err
+    }
+
+    if i < len(lexeme) && lexeme[i] == '.' {
+        if i, err = scanDigits(lexeme, i + 1, 10); err != nil {
+            return 0, err
+        }
+    }
+
+    if i < len(lexeme) && lowerByte(lexeme[i]) == 'e' {
+        exponent := i
+        i++
+        if i < len(lexeme) && (lexeme[i] == '+' || lexeme[i] == '-') {
+            i++
+        }
+
+        digits := i
+        if i, err = scanDigits(lexeme, i, 10); err != nil {
+            return 0, err
+        }
+        if i == digits {
+            return 0, &LiteralError{Start: exponent, End: i, Message: "Expect digits in the exponent."}
+        }
+    }
+
+    if i < len(lexeme) {
+        return 0, invalidCharacter(lexeme, i)
+    }
+
+    // the overflowing literals are infinite, as the results of arithmetic are
+    v, err := strconv.ParseFloat(strings.ReplaceAll(lexeme, "_", ""), 64)
+    if err != nil && !isRangeError(err) {
+        return 0, &LiteralError{Start: 0, End: len(lexeme), Message: "Invalid number literal."}
+    }
+
+    return v, nil
+}
+
+func invalidCharacter(lexeme string, i int) error {
+    return &LiteralError{Start: i, End: i + 1, Message: fmt.Sprintf("Invalid character '%c' in number literal.", lexeme[i])}
+}
+
+func isRangeError(err error) bool {
+    numErr, ok := err.(*strconv.NumError)
+    return ok && numErr.Err == strconv.ErrRange
+}
+
+// the value of an integer literal. false if the literal has a fraction or
+// an exponent, or if it doesn't fit in 64 bits
+func ParseInt(lexeme string) (int64, bool) {
+    if _, err := ParseNumber(lexeme); err != nil {
+        return 0, false
+    }
+
+    base, digits := 10, lexeme
+    if len(lexeme) >= 2 && lexeme[0] == '0' {
+        if prefix, ok := numberBases[lowerByte(lexeme[1])]; ok {
+            base, digits = prefix.base, lexeme[2:]
+        }
+    }
+    if base == 10 && strings.ContainsAny(lexeme, ".eE") {
+        return 0, false
+    }
+
+    n, ok := new(big.Int).SetString(strings.ReplaceAll(digits, "_", ""), base)
+    if !ok || !n.IsInt64() {
+        return 0, false
+    }
+
+    return n.Int64(), true
+}
+
+// the value of the number literal: an int, a number, a big integer or a
+// decimal. the errors are the ones of ParseNumber, and the integers
+// beyond 64 bits without the n suffix
+func ParseLiteral(lexeme string) (ValueType, error) {
+    base, body := 10, lexeme
+    if len(lexeme) >= 2 && lexeme[0] == '0' {
+        if prefix, ok := numberBases[lowerByte(lexeme[1])]; ok {
+            base = prefix.base
+        }
+    }
+
+    suffix := lexeme[len(lexeme)-1]
+    if suffix == 'n' || suffix == 'd' && base == 10 {
+        body = lexeme[:len(lexeme)-1]
+    }
+    if _, err := ParseNumber(body); err != nil {
+        return nil, err
+    }
+
+    digits := strings.ReplaceAll(body, "_", "")
+    isIntegral := base != 10 || !strings.ContainsAny(body, ".eE")
+    switch {
+    case body == lexeme:
+        if n, ok := ParseInt(lexeme); ok {
+            return IntType{v: n}, nil
+        }
+        // rounding the integer to a number would lose its precision
+        if isIntegral {
+            return nil, &LiteralError{Start: 0, End: len(lexeme), Message: "Integer literal too large, use the n suffix."}
+        }
+        v, err := ParseNumber(lexeme)
+        return NumberType{v: v}, err
+    case suffix == 'n':
+        if !isIntegral {
+            return nil, &LiteralError{Start: 0, End: len(lexeme), Message: "A bigint literal must be an integer."}
+        }
+        if base != 10 {
+            digits = digits[2:]
+        }
+        n, _ := new(big.Int).SetString(digits, base)
+        return BigIntType{v: n}, nil
+    default:
+        r, ok := new(big.Rat).SetString(digits)
+        if !ok {
+            return nil, &LiteralError{Start: 0, End: len(lexeme), Message: "Invalid decimal literal."}
+        }
+        return DecimalType{v: r}, nil
+    }
+}
@@ -0,0 +1,64 @@
+package lox
+
+import (
+    "math"
+    "testing"
+)
+
+// the golden outputs of the reference Lox for the numbers printed by the
+// programs, and for the literals printed by the tokenize command
+
+func TestFormatNumber(t *testing.T) {
+    tests := []struct {
+        v float64
+        want string
+    }{
+        {3, "3"},
+        {3.5, "3.5"},
+        {-3.5, "-3.5"},
+        {0, "0"},
+        {math.Copysign(0, -1), "-0"},
+        {123456789012, "123456789012"},
+        {1e20, "100000000000000000000"},
+        {1e21, "1e+21"},
+        {1.5e300, "1.5e+300"},
+        {0.000001, "0.000001"},
+        {1e-7, "1e-7"},
+        {2.5e-10, "2.5e-10"},
+        {-1e-7, "-1e-7"},
+        {math.NaN(), "nan"},
+        {math.Inf(1), "inf"},
+        {math.Inf(-1), "-inf"},
+    }
+
+    for _, tt := range(tests) {
+        if got := FormatNumber(tt.v); got != tt.want {
+            t.Errorf("FormatNumber(%v) = %q, want %q", tt.v, got, tt.want)
+        }
+    }
+}
+
+func TestLiteralString(t *testing.T) {
+    tests := []struct {
+        lexeme string
+        want string
+    }{
+        {"42", "42.0"},
+        {"0", "0.0"},
+        {"3.5", "3.5"},
+        {"100.00", "100.0"},
+        {"0.1", "0.1"},
+        {"1_000", "1000.0"},
+        {"0x10", "16.0"},
+        {"1e21", "1e+21"},
+        {"1e-7", "1e-7"},
+        {"2.5e-10", "2.5e-10"},
+    }
+
+    for _, tt := range(tests) {
+        token := Token{Type: TK_NUMBER, Lexeme: tt.lexeme}
+        if got := token.LiteralString(); got != tt.want {
+            t.Errorf("LiteralString(%s) = %q, want %q", tt.lexeme, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,131 @@
+package lox
+
+import (
+    "fmt"
+)
+
+// the values only used by the virtual machine. they print the same as the
+// values of the interpreter, so that both backends produce identical output
+
+// FunctionObj is a compiled function. the top-level script is a function too
+type FunctionObj struct {
+    name string // empty for the top-level script
+    arity int
+    upvalueCount int
+    chunk *Chunk
+}
+
+func (t *FunctionObj) String() string {
+    if t.name == "" {
+        return "<script>"
+    }
+
+    return fmt.Sprintf("<fn %s>", t.name)
+}
+
+func (t *FunctionObj) Literal() any {
+    return t
+}
+
+func (t *FunctionObj) Type() string {
+    return "function"
+}
+
+func (t *FunctionObj) IsTrue() bool {
+    return true
+}
+
+// UpvalueObj refers to a variable captured by a closure. it points to the
+// stack slot while the variable is alive, and holds the value once closed
+type UpvalueObj struct {
+    slot int
+    closed ValueType
+    isClosed bool
+    next *UpvalueObj // the next open upvalue, ordered by slot descending
+}
+
+// ClosureObj is a function with its captured variables
+type ClosureObj struct {
+    fn *FunctionObj
+    upvalues []*UpvalueObj
+}
+
+func (t *ClosureObj) String() string {
+    return t.fn.String()
+}
+
+func (t *ClosureObj) Literal() any {
+    return t
+}
+
+func (t *ClosureObj) Type() string {
+    return "function"
+}
+
+func (t *ClosureObj) IsTrue() bool {
+    return true
+}
+
+type ClassObj struct {
+    name string
+    methods map[string]*ClosureObj
+}
+
+func (t *ClassObj) String() string {
+    return t.name
+}
+
+func (t *ClassObj) Literal() any {
+    return t
+}
+
+func (t *ClassObj) Type() string {
+    return "class"
+}
+
+func (t *ClassObj) IsTrue() bool {
+    return true
+}
+
+type InstanceObj struct {
+    class *ClassObj
+    fields map[string]ValueType
+}
+
+func (t *InstanceObj) String() string {
+    return t.class.name + " instance"
+}
+
+func (t *InstanceObj) Literal() any {
+    return t
+}
+
+func (t *InstanceObj) Type() string {
+    return "instance"
+}
+
+func (t *InstanceObj) IsTrue() bool {
+    return true
+}
+
+// BoundMethodObj is a method accessed from an instance
+type BoundMethodObj struct {
+    receiver ValueType
+    method *ClosureObj
+}
+
+func (t *BoundMethodObj) String() string {
+    return t.method.String()
+}
+
+func (t *BoundMethodObj) Literal() any {
+    return t
+}
+
+func (t *BoundMethodObj) Type() string {
+    return "function"
+}
+
+func (t *BoundMethodObj) IsTrue() bool {
+    return true
+}
@@ -0,0 +1,875 @@
+package lox
+
+import (
+    "errors"
+    "fmt"
+)
+
+// the maximum nesting of the statements and the expressions, deep enough
+// for the usual programs while the go stack of the recursive descent, the
+// resolver and the interpreter stays small
+const MaxNestingDepth = 1000
+
+type Parser struct {
+    Tokens []Token
+    Current int
+
+    // the nesting of the statement or the expression being parsed
+    depth int
+
+    // the syntax errors collected so far, the parser recovers from
+    // each of them at the next statement boundary
+    Errors []error
+}
+
+// ParseError locates a static error at the offending token, found by the
+// parser or by the resolver
+type ParseError struct {
+    Token *Token
+    Message string
+}
+
+func (e *ParseError) Location() Span {
+    return e.Token.Span()
+}
+
+func (e *ParseError) Error() string {
+    if e.Token.Type == TK_EOF {
+        return fmt.Sprintf("[line %d] Error at end: %s", e.Token.Line, e.Message)
+    }
+
+    return fmt.Sprintf("[line %d] Error at '%s': %s", e.Token.Line, e.Token.Lexeme, e.Message)
+}
+
+func NewParser(tokens []Token) *Parser {
+    return &Parser {
+        Tokens: tokens,
+        Current: 0,
+        Errors: make([]error, 0),
+    }
+}
+
+func (p *Parser) MatchAny(token_types ...string) bool {
+    for _, typ := range token_types {
+        if p.Check(typ) {
+            p.Advance()
+            return true 
+        }
+    }
+
+    return false 
+}
+
+
+func (p *Parser) Advance() *Token {
+    if p.IsEnd() {
+        return &p.Tokens[len(p.Tokens)-1]
+    }
+    
+    token := p.Peek()
+    p.Current++
+    return token
+}
+
+func (p *Parser) Check(token_type string) bool {
+    if p.IsEnd() {
+        return token_type == TK_EOF
+    }
+    return p.Peek().Type == token_type
+}
+
+func (p *Parser) Peek() *Token {
+    // TODO: assert p.Current < len(p.Tokens)
+    return &p.Tokens[p.Current]
+}
+
+func (p *Parser) Previous() *Token {
+    // TODO: assert p.Current - 1 >= 0
+    return &p.Tokens[p.Current-1]
+}
+
+func (p *Parser) IsEnd() bool {
+    return p.Current >= len(p.Tokens) || p.Peek().Type == TK_EOF
+}
+
+// expect and consume the token type. if mismatch, return an error
+func (p *Parser) Expect(tk string, msg string) (*Token, error) {
+    if p.Check(tk) {
+        return p.Advance(), nil
+    }
+
+    return nil, p.Error(p.Peek(), msg)
+}
+
+func (p *Parser) Error(token *Token, msg string) error {
+    return &ParseError{Token: token, Message: msg}
+}
+
+// enter a nested statement or expression, a source nested deeper than
+// MaxNestingDepth is a syntax error rather than a stack overflow
+func (p *Parser) Enter() error {
+    if p.depth >= MaxNestingDepth {
+        return p.Error(p.Peek(), "Too much nesting.")
+    }
+
+    p.depth++
+    return nil
+}
+
+func (p *Parser) Leave() {
+    p.depth--
+}
+
+// parse the whole program. all the syntax errors are joined into the
+// returned error, one per line
+func (p *Parser) Parse() ([]Stmt, error) {
+    stmts := []Stmt{}
+    for !p.IsEnd() {
+        if stmt := p.ParseDeclaration(); stmt != nil {
+            stmts = append(stmts, stmt)
+        }
+    }
+
+    return stmts, errors.Join(p.Errors...)
+}
+
+// parse a statement. if it fails, record the error and skip to the next
+// statement, so that the following errors can be reported too
+func (p *Parser) ParseDeclaration() Stmt {
+    stmt, err := p.ParseStatement()
+    if err != nil {
+        p.Errors = append(p.Errors, err)
+        p.Synchronize()
+        return nil
+    }
+
+    return stmt
+}
+
+// discard the tokens until a statement boundary: after a semicolon,
+// or before a keyword which begins a statement
+func (p *Parser) Synchronize() {
+    p.Advance()
+
+    for !p.IsEnd() {
+        if p.Previous().Type == TK_SEMICOLON {
+            return
+        }
+
+        switch p.Peek().Type {
+        case KW_CLASS, KW_FUN, KW_VAR, KW_FOR, KW_IF, KW_WHILE, KW_PRINT, KW_RETURN:
+            return
+        }
+
+        p.Advance()
+    }
+}
+
+func (p *Parser) ParseStatement() (Stmt, error) {
+    if err := p.Enter(); err != nil {
+        return nil, err
+    }
+    defer p.Leave()
+
+    if p.MatchAny(KW_PRINT, KW_VAR, TK_LEFT_BRACE, KW_IF, KW_WHILE, KW_FOR, KW_FUN, KW_RETURN, KW_CLASS) {
+        switch p.Previous().Type {
+        case KW_PRINT:
+            return p.ParsePrintStatement()
+        case KW_VAR:
+            return p.ParseVarStatement()
+        case TK_LEFT_BRACE:
+            return p.ParseBlock()
+        case KW_IF:
+            return p.ParseIfStatement()
+        case KW_WHILE:
+            return p.ParseWhileStatement()
+        case KW_FOR:
+            return p.ParseForStatement()
+        case KW_FUN:
+            return p.ParseFunction("function")
+        case KW_RETURN:
+            return p.ParseReturnStatement()
+        case KW_CLASS:
+            return p.ParseClass()
+        }
+    }
+
+    return p.ParseExpressionStatement()
+}
+
+func (p *Parser) ParseIfStatement() (Stmt, error) {
+    keyword := p.Previous()
+
+    if _, err := p.Expect(TK_LEFT_PAREN, "Expect '(' after 'if'."); err != nil {
+        return nil, err
+    }
+
+    cond, err := p.ParseExpression()
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err = p.Expect(TK_RIGHT_PAREN, "Expect ')' after if condition."); err != nil {
+        return nil, err
+    }
+
+    then, err := p.ParseStatement()
+    if err != nil {
+        return nil, err
+    }
+
+    // the else is bound to the nearest if that precedes it, for example:
+    //   if (a) if (b) print 1; else print 2;
+    // the else branch belongs to `if (b)`
+    var otherwise Stmt
+    if p.MatchAny(KW_ELSE) {
+        if otherwise, err = p.ParseStatement(); err != nil {
+            return nil, err
+        }
+    }
+
+    return IfStmt{keyword: keyword, cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+func (p *Parser) ParseWhileStatement() (Stmt, error) {
+    keyword := p.Previous()
+
+    if _, err := p.Expect(TK_LEFT_PAREN, "Expect '(' after 'while'."); err != nil {
+        return nil, err
+    }
+
+    cond, err := p.ParseExpression()
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err = p.Expect(TK_RIGHT_PAREN, "Expect ')' after condition."); err != nil {
+        return nil, err
+    }
+
+    body, err := p.ParseStatement()
+    if err != nil {
+        return nil, err
+    }
+
+    return WhileStmt{keyword: keyword, cond: cond, body: body}, nil
+}
+
+// the for loop is desugared into a while loop:
+//   for (init; cond; incr) body
+// becomes
+//   { init; while (cond) { body; incr; } }
+func (p *Parser) ParseForStatement() (Stmt, error) {
+    var err error
+    forTk := p.Previous()
+
+    if _, err = p.Expect(TK_LEFT_PAREN, "Expect '(' after 'for'."); err != nil {
+        return nil, err
+    }
+
+    var init Stmt
+    if p.MatchAny(TK_SEMICOLON) {
+        // no initializer
+    } else if p.MatchAny(KW_VAR) {
+        if init, err = p.ParseVarStatement(); err != nil {
+            return nil, err
+        }
+    } else {
+        if init, err = p.ParseExpressionStatement(); err != nil {
+            return nil, err
+        }
+    }
+
+    var cond Expr
+    if !p.Check(TK_SEMICOLON) {
+        if cond, err = p.ParseExpression(); err != nil {
+            return nil, err
+        }
+    }
+
+    if _, err = p.Expect(TK_SEMICOLON, "Expect ';' after loop condition."); err != nil {
+        return nil, err
+    }
+
+    var incr Expr
+    if !p.Check(TK_RIGHT_PAREN) {
+        if incr, err = p.ParseExpression(); err != nil {
+            return nil, err
+        }
+    }
+
+    if _, err = p.Expect(TK_RIGHT_PAREN, "Expect ')' after for clauses."); err != nil {
+        return nil, err
+    }
+
+    body, err := p.ParseStatement()
+    if err != nil {
+        return nil, err
+    }
+
+    if incr != nil {
+        body = BlockStmt{stmts: []Stmt{body, ExprStmt{v: incr}}}
+    }
+
+    if cond == nil {
+        cond = NewLiteralExpr(&Token{Type: KW_TRUE, Lexeme: "true", Line: forTk.Line, Column: forTk.Column})
+    }
+    body = WhileStmt{keyword: forTk, cond: cond, body: body}
+
+    if init != nil {
+        body = BlockStmt{stmts: []Stmt{init, body}}
+    }
+
+    return body, nil
+}
+
+func (p *Parser) ParseBlock() (Stmt, error) {
+    stmts, err := p.ParseBlockStatements()
+    if err != nil {
+        return nil, err
+    }
+
+    return BlockStmt{stmts: stmts}, nil
+}
+
+// parse the statements until the closing brace, the opening brace must be consumed
+func (p *Parser) ParseBlockStatements() ([]Stmt, error) {
+    stmts := []Stmt{}
+    for !p.IsEnd() && !p.Check(TK_RIGHT_BRACE) {
+        if stmt := p.ParseDeclaration(); stmt != nil {
+            stmts = append(stmts, stmt)
+        }
+    }
+
+    if _, err := p.Expect(TK_RIGHT_BRACE, "Expect '}' after block."); err != nil {
+        return nil, err
+    }
+
+    return stmts, nil
+}
+
+func (p *Parser) ParseClass() (Stmt, error) {
+    name, err := p.Expect(TK_IDENTIFIER, "Expect class name.")
+    if err != nil {
+        return nil, err
+    }
+
+    var superclass *VarExpr
+    if p.MatchAny(TK_LESS) {
+        tk, err := p.Expect(TK_IDENTIFIER, "Expect superclass name.")
+        if err != nil {
+            return nil, err
+        }
+        superclass = &VarExpr{token: tk}
+    }
+
+    if _, err = p.Expect(TK_LEFT_BRACE, "Expect '{' before class body."); err != nil {
+        return nil, err
+    }
+
+    methods := []*FunStmt{}
+    for !p.IsEnd() && !p.Check(TK_RIGHT_BRACE) {
+        method, err := p.ParseFunction("method")
+        if err != nil {
+            return nil, err
+        }
+        methods = append(methods, method)
+    }
+
+    if _, err = p.Expect(TK_RIGHT_BRACE, "Expect '}' after class body."); err != nil {
+        return nil, err
+    }
+
+    return ClassStmt{name: name, superclass: superclass, methods: methods}, nil
+}
+
+// the kind is used in error messages, such as function or method
+func (p *Parser) ParseFunction(kind string) (*FunStmt, error) {
+    name, err := p.Expect(TK_IDENTIFIER, fmt.Sprintf("Expect %s name.", kind))
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err = p.Expect(TK_LEFT_PAREN, fmt.Sprintf("Expect '(' after %s name.", kind)); err != nil {
+        return nil, err
+    }
+
+    params := []*Token{}
+    if !p.Check(TK_RIGHT_PAREN) {
+        for {
+            if len(params) >= MaxArity {
+                return nil, p.Error(p.Peek(), fmt.Sprintf("Can't have more than %d parameters.", MaxArity))
+            }
+
+            param, err := p.Expect(TK_IDENTIFIER, "Expect parameter name.")
+            if err != nil {
+                return nil, err
+            }
+            params = append(params, param)
+
+            if !p.MatchAny(TK_COMMA) {
+                break
+            }
+        }
+    }
+
+    if _, err = p.Expect(TK_RIGHT_PAREN, "Expect ')' after parameters."); err != nil {
+        return nil, err
+    }
+
+    if _, err = p.Expect(TK_LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body.", kind)); err != nil {
+        return nil, err
+    }
+
+    body, err := p.ParseBlockStatements()
+    if err != nil {
+        return nil, err
+    }
+
+    return &FunStmt{name: name, params: params, body: body}, nil
+}
+
+func (p *Parser) ParseReturnStatement() (Stmt, error) {
+    keyword := p.Previous()
+
+    var expr Expr
+    var err error
+    if !p.Check(TK_SEMICOLON) {
+        if expr, err = p.ParseExpression(); err != nil {
+            return nil, err
+        }
+    }
+
+    if _, err = p.Expect(TK_SEMICOLON, "Expect ';' after return value."); err != nil {
+        return nil, err
+    }
+
+    return ReturnStmt{keyword: keyword, v: expr}, nil
+}
+
+func (p *Parser) ParseVarStatement() (Stmt, error) {
+    var tk *Token
+    var err error
+
+    // we can handle the following use cases:
+    //  1) var a;
+    //  2) var a=1;
+    //  3) var a=b;
+    //  4) var a=b=4;
+    if tk, err = p.Expect(TK_IDENTIFIER, "Expect variable name."); err != nil {
+        return nil, err
+    }
+
+    var expr Expr
+
+    if p.MatchAny(TK_EQUAL) {
+        if expr, err = p.ParseExpression(); err != nil {
+            return nil, err
+        }
+    }
+
+    if _, err = p.Expect(TK_SEMICOLON, "Expect ';' after variable declaration."); err != nil {
+        return nil, err
+    }
+
+    return VarStmt{v: expr, tk: tk}, nil
+}
+
+func (p *Parser) ParseExpressionStatement() (Stmt, error) {
+    expr, err := p.ParseExpression()
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err = p.Expect(TK_SEMICOLON, "Expect ';' after expression."); err != nil {
+        return nil, err
+    }
+
+    return ExprStmt{v: expr}, nil
+}
+
+func (p *Parser) ParsePrintStatement() (Stmt, error) {
+    keyword := p.Previous()
+
+    expr, err := p.ParseExpression()
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err = p.Expect(TK_SEMICOLON, "Expect ';' after value."); err != nil {
+        return nil, err
+    }
+
+    return PrintStmt{keyword: keyword, v: expr}, nil
+}
+
+func (p *Parser) ParseExpression() (Expr, error) {
+    if err := p.Enter(); err != nil {
+        return nil, err
+    }
+    defer p.Leave()
+
+    return p.ParseAssignment()
+}
+
+func (p *Parser) ParseAssignment() (Expr, error) {
+    expr, err := p.ParseOr()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    // we can handle the following use cases:
+    //   1) a=b=123
+    //   2) a=b
+    //   3) a=123
+    if p.MatchAny(TK_EQUAL) {
+        equals := p.Previous()
+        if err := p.Enter(); err != nil {
+            return nil, err
+        }
+        val, err := p.ParseAssignment()
+        p.Leave()
+        if err != nil {
+            return nil, err
+        }
+
+        if v, ok := expr.(VarExpr); ok {
+            expr = AssignmentExpr{token: v.token, expr: val}
+        } else if v, ok := expr.(GetExpr); ok {
+            expr = SetExpr{object: v.object, name: v.name, value: val}
+        } else {
+            return nil, p.Error(equals, "Invalid assignment target.")
+        }
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) ParseOr() (Expr, error) {
+    expr, err := p.ParseAnd()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    for p.MatchAny(KW_OR) {
+        optr := p.Previous()
+        right, err := p.ParseAnd()
+        if err != nil {
+            return nil, err
+        }
+        expr = NewLogicalExpr(expr, optr, right)
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) ParseAnd() (Expr, error) {
+    expr, err := p.ParseEquality()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    for p.MatchAny(KW_AND) {
+        optr := p.Previous()
+        right, err := p.ParseEquality()
+        if err != nil {
+            return nil, err
+        }
+        expr = NewLogicalExpr(expr, optr, right)
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) ParseEquality() (Expr, error) {
+    expr, err := p.ParseComparsion()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    for p.MatchAny(TK_EQUAL_EQUAL, TK_BANG_EQUAL) {
+        optr := p.Previous()
+        right, err := p.ParseComparsion()
+        if err != nil {
+            return nil, err
+        }
+        expr = NewBinaryExpr(expr, optr, right)
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) ParseComparsion() (Expr, error) {
+    expr, err := p.ParseBitOr()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    for p.MatchAny(TK_GREATER, TK_GREATER_EQUAL, TK_LESS, TK_LESS_EQUAL) {
+        optr := p.Previous()
+        right, err := p.ParseBitOr()
+        if err != nil {
+            return nil, err
+        }
+        expr = NewBinaryExpr(expr, optr, right)
+    }
+
+    return expr, nil
+}
+
+// the bitwise operators bind tighter than the comparisons, unlike in C:
+// a & 1 == 0 is (a & 1) == 0
+func (p *Parser) ParseBitOr() (Expr, error) {
+    return p.ParseLeftAssoc(p.ParseBitXor, TK_PIPE)
+}
+
+func (p *Parser) ParseBitXor() (Expr, error) {
+    return p.ParseLeftAssoc(p.ParseBitAnd, TK_CARET)
+}
+
+func (p *Parser) ParseBitAnd() (Expr, error) {
+    return p.ParseLeftAssoc(p.ParseShift, TK_AMPERSAND)
+}
+
+func (p *Parser) ParseShift() (Expr, error) {
+    return p.ParseLeftAssoc(p.ParseTerm, TK_LESS_LESS, TK_GREATER_GREATER)
+}
+
+// parse the left-associative binary operators of one precedence level,
+// with the operands of the next level
+func (p *Parser) ParseLeftAssoc(operand func() (Expr, error), optrs ...string) (Expr, error) {
+    expr, err := operand()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    for p.MatchAny(optrs...) {
+        optr := p.Previous()
+        right, err := operand()
+        if err != nil {
+            return nil, err
+        }
+        expr = NewBinaryExpr(expr, optr, right)
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) ParseTerm() (Expr, error) {
+    expr, err := p.ParseFactor()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    for p.MatchAny(TK_MINUS, TK_PLUS) {
+        optr := p.Previous()
+        right, err := p.ParseFactor()
+        if err != nil {
+            return nil, err
+        }
+        expr = NewBinaryExpr(expr, optr, right)
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) ParseFactor() (Expr, error) {
+    expr, err := p.ParseUnary()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    for p.MatchAny(TK_STAR, TK_SLASH, TK_TILDE_SLASH, TK_PERCENT) {
+        optr := p.Previous()
+        right, err := p.ParseUnary()
+        if err != nil {
+            return nil, err
+        }
+        expr = NewBinaryExpr(expr, optr, right)
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) ParseUnary() (Expr, error) {
+    if p.MatchAny(TK_BANG, TK_MINUS, TK_TILDE) {
+        optr := p.Previous()
+        if err := p.Enter(); err != nil {
+            return nil, err
+        }
+        expr, err := p.ParseUnary()
+        p.Leave()
+        if err != nil {
+            return nil, err
+        }
+        return UnaryExpr{token: optr, expr: expr}, nil
+    }
+
+    return p.ParsePower()
+}
+
+// the exponent binds tighter than the unary operators on its left, and
+// it's right-associative: -2 ** 2 is -(2 ** 2), 2 ** 3 ** 2 is 2 ** (3 ** 2)
+func (p *Parser) ParsePower() (Expr, error) {
+    expr, err := p.ParseCall()
+    if err != nil {
+        return nil, err
+    }
+
+    if p.MatchAny(TK_STAR_STAR) {
+        optr := p.Previous()
+        if err := p.Enter(); err != nil {
+            return nil, err
+        }
+        right, err := p.ParseUnary()
+        p.Leave()
+        if err != nil {
+            return nil, err
+        }
+        expr = NewBinaryExpr(expr, optr, right)
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) ParseCall() (Expr, error) {
+    expr, err := p.ParsePrimary()
+    if err != nil {
+        return nil, err
+    }
+
+    // recursive descent parse
+    // we can handle the following use cases:
+    //   1) f()
+    //   2) f(1, 2)
+    //   3) f(1)(2)
+    //   4) a.b.c()
+    for p.MatchAny(TK_LEFT_PAREN, TK_DOT) {
+        if p.Previous().Type == TK_LEFT_PAREN {
+            if expr, err = p.FinishCall(expr); err != nil {
+                return nil, err
+            }
+        } else {
+            name, err := p.Expect(TK_IDENTIFIER, "Expect property name after '.'.")
+            if err != nil {
+                return nil, err
+            }
+            expr = NewGetExpr(expr, name)
+        }
+    }
+
+    return expr, nil
+}
+
+func (p *Parser) FinishCall(callee Expr) (Expr, error) {
+    args := []Expr{}
+    if !p.Check(TK_RIGHT_PAREN) {
+        for {
+            if len(args) >= MaxArity {
+                return nil, p.Error(p.Peek(), fmt.Sprintf("Can't have more than %d arguments.", MaxArity))
+            }
+
+            arg, err := p.ParseExpression()
+            if err != nil {
+                return nil, err
+            }
+            args = append(args, arg)
+
+            if !p.MatchAny(TK_COMMA) {
+                break
+            }
+        }
+    }
+
+    paren, err := p.Expect(TK_RIGHT_PAREN, "Expect ')' after arguments.")
+    if err != nil {
+        return nil, err
+    }
+
+    return NewCallExpr(callee, paren, args), nil
+}
+
+func (p *Parser) ParsePrimary() (Expr, error) {
+    if p.MatchAny(TK_NUMBER, TK_STRING, KW_TRUE, KW_FALSE, KW_NIL) {
+        return NewLiteralExpr(p.Previous()), nil
+    } else if p.MatchAny(TK_INTERPOLATION) {
+        return p.ParseInterpolation()
+    } else if p.MatchAny(TK_LEFT_PAREN) {
+        lparen := p.Previous()
+        expr, err := p.ParseExpression()
+        if err != nil {
+            return nil, err
+        }
+        rparen, err := p.Expect(TK_RIGHT_PAREN, "Expect ')' after expression.")
+        if err != nil {
+            return nil, err
+        }
+        return GroupExpr{expr: expr, lparen: lparen, rparen: rparen}, nil
+    } else if p.MatchAny(TK_IDENTIFIER) {
+        return VarExpr{token: p.Previous()}, nil
+    } else if p.MatchAny(KW_THIS) {
+        return ThisExpr{keyword: p.Previous()}, nil
+    } else if p.MatchAny(KW_SUPER) {
+        keyword := p.Previous()
+        if _, err := p.Expect(TK_DOT, "Expect '.' after 'super'."); err != nil {
+            return nil, err
+        }
+        method, err := p.Expect(TK_IDENTIFIER, "Expect superclass method name.")
+        if err != nil {
+            return nil, err
+        }
+        return SuperExpr{keyword: keyword, method: method}, nil
+    }
+
+    return nil, p.Error(p.Peek(), "Expect expression.")
+}
+
+// the interpolated string is lowered into the concatenation of its parts,
+// each expression is converted to string and the empty parts are left out:
+//   "a ${b} c ${d}" is (("a " + (str b)) + " c ") + (str d)
+func (p *Parser) ParseInterpolation() (Expr, error) {
+    var expr Expr
+    part := p.Previous()
+    for {
+        expr = concat(expr, part, NewLiteralExpr(part), part.Text == "")
+
+        inner, err := p.ParseExpression()
+        if err != nil {
+            return nil, err
+        }
+        expr = concat(expr, part, StringifyExpr{expr: inner}, false)
+
+        if p.MatchAny(TK_INTERPOLATION) {
+            part = p.Previous()
+            continue
+        }
+
+        end, err := p.Expect(TK_STRING, "Expect '}' after interpolated expression.")
+        if err != nil {
+            return nil, err
+        }
+        return concat(expr, end, NewLiteralExpr(end), end.Text == ""), nil
+    }
+}
+
+// append the operand to the concatenation, unless it's skipped. the plus
+// operator is made up at the string part, which locates the errors
+func concat(expr Expr, at *Token, operand Expr, skip bool) Expr {
+    if skip {
+        return expr
+    }
+    if expr == nil {
+        return operand
+    }
+
+    optr := &Token{Type: TK_PLUS, Lexeme: "+", Line: at.Line, Column: at.Column, Start: at.Start, End: at.End}
+    return NewBinaryExpr(expr, optr, operand)
+}
@@ -0,0 +1,139 @@
+package lox
+
+import (
+    "fmt"
+    "io/fs"
+    "path/filepath"
+    "strings"
+)
+
+// Permissions tell what the natives may touch on behalf of the program.
+// the programs may read and write only the files under the roots
+type Permissions struct {
+    ReadRoots []string
+    WriteRoots []string
+
+    // reading the environment variables with getenv()
+    Env bool
+
+    // running commands with exec()
+    Exec bool
+
+    // reading the time with clock()
+    Clock bool
+}
+
+// the permissions of a new interpreter: only the clock is allowed
+var DefaultPermissions = Permissions{Clock: true}
+
+// grant the permissions to the natives, replacing DefaultPermissions
+func WithPermissions(p Permissions) Option {
+    return func(in *Interpreter) {
+        in.permissions = p
+    }
+}
+
+// PermissionError is raised by the natives doing what the permissions
+// don't allow. it wraps fs.ErrPermission
+type PermissionError struct {
+    Op string
+    Target string
+}
+
+func (e *PermissionError) Error() string {
+    if e.Target == "" {
+        return fmt.Sprintf("Permission denied: %s.", e.Op)
+    }
+
+    return fmt.Sprintf("Permission denied: %s '%s'.", e.Op, e.Target)
+}
+
+func (e *PermissionError) Unwrap() error {
+    return fs.ErrPermission
+}
+
+// the checks below are also meant for the natives defined by the host
+
+func (in *Interpreter) CheckRead(path string) error {
+    if !underRoots(path, in.permissions.ReadRoots) {
+        return &PermissionError{Op: "read", Target: path}
+    }
+
+    return nil
+}
+
+func (in *Interpreter) CheckWrite(path string) error {
+    if !underRoots(path, in.permissions.WriteRoots) {
+        return &PermissionError{Op: "write", Target: path}
+    }
+
+    return nil
+}
+
+func (in *Interpreter) CheckEnv(name string) error {
+    if !in.permissions.Env {
+        return &PermissionError{Op: "read environment variable", Target: name}
+    }
+
+    return nil
+}
+
+func (in *Interpreter) CheckExec(command string) error {
+    if !in.permissions.Exec {
+        return &PermissionError{Op: "run", Target: command}
+    }
+
+    return nil
+}
+
+func (in *Interpreter) CheckClock() error {
+    if !in.permissions.Clock {
+        return &PermissionError{Op: "read clock"}
+    }
+
+    return nil
+}
+
+// tell whether the path is one of the roots or under one of them. the
+// symbolic links are followed, so that a link can't escape from the roots
+func underRoots(path string, roots []string) bool {
+    target, err := realPath(path)
+    if err != nil {
+        return false
+    }
+
+    for _, root := range(roots) {
+        dir, err := realPath(root)
+        if err != nil {
+            continue
+        }
+
+        rel, err := filepath.Rel(dir, target)
+        if err == nil && rel != ".." && !strings.HasPrefix(rel, ".." + string(filepath.Separator)) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// the absolute path with the symbolic links resolved. the file may not
+// exist yet, for example the one being written, then its directory is
+// resolved instead
+func realPath(path string) (string, error) {
+    abs, err := filepath.Abs(path)
+    if err != nil {
+        return "", err
+    }
+
+    if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+        return resolved, nil
+    }
+
+    dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+    if err != nil {
+        return "", err
+    }
+
+    return filepath.Join(dir, filepath.Base(abs)), nil
+}
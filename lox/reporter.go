@@ -0,0 +1,149 @@
+package lox
+
+import (
+    "fmt"
+    "io"
+    "strings"
+)
+
+// Located is implemented by the errors which know where they happen
+type Located interface {
+    Location() Span
+}
+
+// SpanError is an error located at a span of the source. the message is
+// the same as the error it wraps
+type SpanError struct {
+    Span Span
+    Message string
+}
+
+func (e *SpanError) Error() string {
+    return e.Message
+}
+
+func (e *SpanError) Location() Span {
+    return e.Span
+}
+
+// the number of frames printed at each end of a deep call stack
+const MaxPrintedFrames = 10
+
+// Reporter prints the errors of one source. unless the plain format is
+// requested, the offending line is printed with the location underlined:
+//   [line 1] Error at ';': Expect expression.
+//       1 | print 1 +;
+//         |          ^
+type Reporter struct {
+    Source string
+    Plain bool
+    Out io.Writer
+}
+
+func NewReporter(out io.Writer) *Reporter {
+    return &Reporter {
+        Out: out,
+    }
+}
+
+func (r *Reporter) Report(msg string, span Span) {
+    fmt.Fprintln(r.Out, msg)
+
+    if !r.Plain {
+        r.PrintExcerpt(span)
+    }
+}
+
+// print the error, or each of the errors joined by the parser
+func (r *Reporter) PrintError(err error) {
+    if joined, ok := err.(interface{ Unwrap() []error }); ok {
+        for _, e := range(joined.Unwrap()) {
+            r.PrintError(e)
+        }
+        return
+    }
+
+    if rt, ok := err.(*RuntimeError); ok {
+        r.PrintRuntimeError(rt)
+        return
+    }
+
+    if located, ok := err.(Located); ok {
+        r.Report(err.Error(), located.Location())
+    } else {
+        fmt.Fprintln(r.Out, err.Error())
+    }
+}
+
+// the plain format only tells the line, as the book does:
+//   Operands must be numbers.
+//   [line 1]
+// otherwise the location is underlined and followed by the call stack:
+//   Operands must be numbers.
+//       1 | fun f() { return -"a"; }
+//         |                  ^~~~
+//   [line 1] in f()
+//   [line 2] in script
+func (r *Reporter) PrintRuntimeError(err *RuntimeError) {
+    fmt.Fprintln(r.Out, err.Error())
+
+    if r.Plain {
+        fmt.Fprintf(r.Out, "[line %d]\n", err.Line())
+        return
+    }
+
+    r.PrintExcerpt(err.Location())
+
+    // the middle of a deep call stack, such as the one of a stack overflow,
+    // is left out
+    frames := err.Frames
+    if len(frames) > 2 * MaxPrintedFrames {
+        for _, frame := range(frames[:MaxPrintedFrames]) {
+            fmt.Fprintln(r.Out, frame.String())
+        }
+        fmt.Fprintf(r.Out, "... %d more frames\n", len(frames) - 2 * MaxPrintedFrames)
+        frames = frames[len(frames) - MaxPrintedFrames:]
+    }
+
+    for _, frame := range(frames) {
+        fmt.Fprintln(r.Out, frame.String())
+    }
+}
+
+func (r *Reporter) PrintExcerpt(span Span) {
+    lines := strings.Split(r.Source, "\n")
+    if span.IsZero() || span.Line > len(lines) {
+        return
+    }
+
+    line := strings.TrimRight(lines[span.Line - 1], "\r")
+    gutter := fmt.Sprintf("%5d | ", span.Line)
+    fmt.Fprintf(r.Out, "%s%s\n", gutter, line)
+
+    // the spans made up without a range only point to the line
+    if span.End <= span.Start || span.Column < 1 || span.Column > len(line) + 1 {
+        return
+    }
+
+    // keep the tabs in the padding, so that the caret is aligned
+    var pad strings.Builder
+    for _, ch := range(line[:span.Column - 1]) {
+        if ch == '\t' {
+            pad.WriteRune('\t')
+        } else {
+            pad.WriteRune(' ')
+        }
+    }
+
+    // the underline is clipped at the end of line
+    width := span.End - span.Start
+    if rest := len(line) - (span.Column - 1); width > rest {
+        width = rest
+    }
+    if width < 1 {
+        width = 1
+    }
+
+    blank := strings.Repeat(" ", len(gutter) - 2)
+    fmt.Fprintf(r.Out, "%s| %s^%s\n", blank, pad.String(), strings.Repeat("~", width - 1))
+}
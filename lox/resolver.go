@@ -0,0 +1,251 @@
+package lox
+
+import (
+    "fmt"
+)
+
+const (
+    FN_NONE = iota
+    FN_FUNCTION
+    FN_METHOD
+    FN_INITIALIZER
+)
+
+const (
+    CLS_NONE = iota
+    CLS_CLASS
+    CLS_SUBCLASS
+)
+
+// Resolver is a static pass between the parser and the interpreter. it binds
+// every variable reference to the scope where it's declared, and reports the
+// semantic errors which the parser can't find
+type Resolver struct {
+    // the local scopes, the global scope is not tracked.
+    // the value tells whether the variable has finished its initializer
+    scopes []map[string]bool
+
+    currentFunction int
+    currentClass int
+
+    // the scope depth of every resolved local variable reference, keyed by
+    // the token of the reference. the variables not found here are globals
+    locals map[*Token]int
+
+    Errors []error
+}
+
+func NewResolver(locals map[*Token]int) *Resolver {
+    return &Resolver {
+        scopes: make([]map[string]bool, 0),
+        currentFunction: FN_NONE,
+        currentClass: CLS_NONE,
+        locals: locals,
+        Errors: make([]error, 0),
+    }
+}
+
+func (r *Resolver) HasError() bool {
+    return len(r.Errors) > 0
+}
+
+func (r *Resolver) Report(token *Token, format string, args ...any) {
+    r.Errors = append(r.Errors, &ParseError{Token: token, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *Resolver) Resolve(stmts []Stmt) {
+    for _, stmt := range(stmts) {
+        r.ResolveStmt(stmt)
+    }
+}
+
+func (r *Resolver) BeginScope() {
+    r.scopes = append(r.scopes, make(map[string]bool))
+}
+
+func (r *Resolver) EndScope() {
+    r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *Resolver) Declare(name *Token) {
+    if len(r.scopes) == 0 {
+        return
+    }
+
+    scope := r.scopes[len(r.scopes)-1]
+    if _, ok := scope[name.Lexeme]; ok {
+        r.Report(name, "Already a variable with this name in this scope.")
+    }
+
+    scope[name.Lexeme] = false
+}
+
+func (r *Resolver) Define(name *Token) {
+    if len(r.scopes) == 0 {
+        return
+    }
+
+    r.scopes[len(r.scopes)-1][name.Lexeme] = true
+}
+
+// record the distance between the innermost scope and the scope declaring
+// the variable. if it's not found, assume it's a global variable
+func (r *Resolver) ResolveLocal(name *Token) {
+    for i := len(r.scopes) - 1; i >= 0; i-- {
+        if _, ok := r.scopes[i][name.Lexeme]; ok {
+            r.locals[name] = len(r.scopes) - 1 - i
+            return
+        }
+    }
+}
+
+func (r *Resolver) ResolveFunction(fn *FunStmt, typ int) {
+    enclosing := r.currentFunction
+    r.currentFunction = typ
+
+    r.BeginScope()
+    for _, param := range(fn.params) {
+        r.Declare(param)
+        r.Define(param)
+    }
+    r.Resolve(fn.body)
+    r.EndScope()
+
+    r.currentFunction = enclosing
+}
+
+func (r *Resolver) ResolveStmt(stmt Stmt) {
+    switch s := stmt.(type) {
+    case BlockStmt:
+        r.BeginScope()
+        r.Resolve(s.stmts)
+        r.EndScope()
+    case VarStmt:
+        r.Declare(s.tk)
+        if s.v != nil {
+            r.ResolveExpr(s.v)
+        }
+        r.Define(s.tk)
+    case *FunStmt:
+        // define the name eagerly, so that the function can refer to itself
+        r.Declare(s.name)
+        r.Define(s.name)
+        r.ResolveFunction(s, FN_FUNCTION)
+    case ClassStmt:
+        r.ResolveClass(s)
+    case ExprStmt:
+        r.ResolveExpr(s.v)
+    case PrintStmt:
+        r.ResolveExpr(s.v)
+    case IfStmt:
+        r.ResolveExpr(s.cond)
+        r.ResolveStmt(s.then)
+        if s.otherwise != nil {
+            r.ResolveStmt(s.otherwise)
+        }
+    case WhileStmt:
+        r.ResolveExpr(s.cond)
+        r.ResolveStmt(s.body)
+    case ReturnStmt:
+        if r.currentFunction == FN_NONE {
+            r.Report(s.keyword, "Can't return from top-level code.")
+        }
+
+        if s.v != nil {
+            if r.currentFunction == FN_INITIALIZER {
+                r.Report(s.keyword, "Can't return a value from an initializer.")
+            }
+            r.ResolveExpr(s.v)
+        }
+    }
+}
+
+func (r *Resolver) ResolveClass(s ClassStmt) {
+    enclosing := r.currentClass
+    r.currentClass = CLS_CLASS
+
+    r.Declare(s.name)
+    r.Define(s.name)
+
+    if s.superclass != nil {
+        if s.superclass.token.Lexeme == s.name.Lexeme {
+            r.Report(s.superclass.token, "A class can't inherit from itself.")
+        }
+
+        r.currentClass = CLS_SUBCLASS
+        r.ResolveExpr(*s.superclass)
+
+        r.BeginScope()
+        r.scopes[len(r.scopes)-1]["super"] = true
+    }
+
+    r.BeginScope()
+    r.scopes[len(r.scopes)-1]["this"] = true
+
+    for _, method := range(s.methods) {
+        typ := FN_METHOD
+        if method.name.Lexeme == "init" {
+            typ = FN_INITIALIZER
+        }
+        r.ResolveFunction(method, typ)
+    }
+
+    r.EndScope()
+
+    if s.superclass != nil {
+        r.EndScope()
+    }
+
+    r.currentClass = enclosing
+}
+
+func (r *Resolver) ResolveExpr(expr Expr) {
+    switch e := expr.(type) {
+    case VarExpr:
+        if len(r.scopes) > 0 {
+            if defined, ok := r.scopes[len(r.scopes)-1][e.token.Lexeme]; ok && !defined {
+                r.Report(e.token, "Can't read local variable in its own initializer.")
+            }
+        }
+        r.ResolveLocal(e.token)
+    case AssignmentExpr:
+        r.ResolveExpr(e.expr)
+        r.ResolveLocal(e.token)
+    case LiteralExpr:
+    case GroupExpr:
+        r.ResolveExpr(e.expr)
+    case UnaryExpr:
+        r.ResolveExpr(e.expr)
+    case StringifyExpr:
+        r.ResolveExpr(e.expr)
+    case BinaryExpr:
+        r.ResolveExpr(e.left)
+        r.ResolveExpr(e.right)
+    case LogicalExpr:
+        r.ResolveExpr(e.left)
+        r.ResolveExpr(e.right)
+    case CallExpr:
+        r.ResolveExpr(e.callee)
+        for _, arg := range(e.args) {
+            r.ResolveExpr(arg)
+        }
+    case GetExpr:
+        r.ResolveExpr(e.object)
+    case SetExpr:
+        r.ResolveExpr(e.value)
+        r.ResolveExpr(e.object)
+    case ThisExpr:
+        if r.currentClass == CLS_NONE {
+            r.Report(e.keyword, "Can't use 'this' outside of a class.")
+            return
+        }
+        r.ResolveLocal(e.keyword)
+    case SuperExpr:
+        if r.currentClass == CLS_NONE {
+            r.Report(e.keyword, "Can't use 'super' outside of a class.")
+        } else if r.currentClass != CLS_SUBCLASS {
+            r.Report(e.keyword, "Can't use 'super' in a class with no superclass.")
+        }
+        r.ResolveLocal(e.keyword)
+    }
+}
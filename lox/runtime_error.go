@@ -0,0 +1,109 @@
+package lox
+
+import (
+    "fmt"
+)
+
+// StackFrame is one function call being unwound by a runtime error
+type StackFrame struct {
+    Function string // empty for the top-level script
+    File string
+    Line int
+}
+
+func (f StackFrame) String() string {
+    if f.Function == "" {
+        return fmt.Sprintf("[line %d] in script", f.Line)
+    }
+
+    return fmt.Sprintf("[line %d] in %s()", f.Line, f.Function)
+}
+
+// RuntimeError is an error raised by the running program. it records the
+// token where it happens, and the call stack from the innermost frame
+type RuntimeError struct {
+    Token *Token
+    Span Span
+    Message string
+    Frames []StackFrame
+
+    // the types of the operands if an operator is applied to wrong operands,
+    // for example: ["number", "string"] for `1 - "a"`
+    Operands []string
+
+    // the go error wrapped, such as ErrCancelled or the error returned by
+    // a native function. nil for the errors of the program itself
+    Err error
+
+    // the line reached in the frame being unwound
+    line int
+}
+
+func NewRuntimeError(token *Token, span Span, msg string) *RuntimeError {
+    line := span.Line
+    if token != nil {
+        line = token.Line
+    }
+
+    return &RuntimeError {
+        Token: token,
+        Span: span,
+        Message: msg,
+        Frames: make([]StackFrame, 0),
+        line: line,
+    }
+}
+
+// the error of the operator whose operands have unexpected types
+func NewOperandError(optr *Token, msg string, operands ...ValueType) *RuntimeError {
+    err := NewRuntimeError(optr, optr.Span(), msg)
+    for _, operand := range(operands) {
+        err.Operands = append(err.Operands, operand.Type())
+    }
+
+    return err
+}
+
+func (e *RuntimeError) Error() string {
+    return e.Message
+}
+
+func (e *RuntimeError) Unwrap() error {
+    return e.Err
+}
+
+func (e *RuntimeError) Location() Span {
+    return e.Span
+}
+
+// the line where the error happens
+func (e *RuntimeError) Line() int {
+    if len(e.Frames) > 0 {
+        return e.Frames[0].Line
+    }
+
+    return e.line
+}
+
+// the error leaves the function declared in the file
+func (e *RuntimeError) PushFrame(function string, file string) {
+    e.Frames = append(e.Frames, StackFrame{Function: function, File: file, Line: e.line})
+}
+
+// the error continues to unwind from the call at the token
+func (e *RuntimeError) SetCallSite(token *Token) {
+    e.line = token.Line
+}
+
+// wrap the error raised at the token into a runtime error. the errors
+// already wrapped by an inner node and the return signal are left as they are
+func Locate(err error, token *Token, span Span) error {
+    switch err.(type) {
+    case nil, *RuntimeError, *ReturnSignal:
+        return err
+    }
+
+    rt := NewRuntimeError(token, span, err.Error())
+    rt.Err = err
+    return rt
+}
@@ -0,0 +1,424 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Scanner struct {
+    Source string
+    Start int
+    Current int
+    Line int
+    LineStart int // byte offset of the current line
+
+    // the line and its offset where the current token starts, which differ
+    // from the current ones after a multi-line string
+    StartLine int
+    StartLineStart int
+
+    // the depth of the braces nested in each string interpolation being
+    // scanned, the string goes on after the brace closing the interpolation
+    Interpolations []int
+
+    Tokens []Token
+
+    // the lexical errors, the scanning goes on after each of them
+    Errors []error
+
+    ReservedKws map[string]string
+}
+
+func (s *Scanner) HasError() bool {
+    return len(s.Errors) > 0
+}
+
+func NewScanner(source string) *Scanner {
+    return &Scanner {
+        Source: source,
+        Start: 0,
+        Current: 0,
+        Line: 1,
+        Tokens: make([]Token, 0),
+        Errors: make([]error, 0),
+        ReservedKws: map[string]string{
+            "and": KW_AND,
+            "class": KW_CLASS,
+            "else": KW_ELSE,
+            "false": KW_FALSE,
+            "true": KW_TRUE,
+            "for": KW_FOR,
+            "fun": KW_FUN,
+            "if": KW_IF,
+            "nil": KW_NIL,
+            "or": KW_OR,
+            "print": KW_PRINT,
+            "return": KW_RETURN,
+            "super": KW_SUPER,
+            "this": KW_THIS,
+            "var": KW_VAR,
+            "while": KW_WHILE,
+        },
+    }
+}
+
+func (s *Scanner) IsEnd() bool {
+    return s.Current >= len(s.Source)
+}
+
+func (s *Scanner) ScanTokens() []Token {
+    for !s.IsEnd() {
+        s.StartToken()
+        s.ScanToken()
+    }
+
+    if len(s.Interpolations) > 0 {
+        s.Report("Unterminated string interpolation.")
+    }
+
+    s.StartToken()
+    s.AddToken(TK_EOF)
+    
+    return s.Tokens
+}
+
+func (s *Scanner) StartToken() {
+    s.Start = s.Current
+    s.StartLine = s.Line
+    s.StartLineStart = s.LineStart
+}
+
+func (s *Scanner) Advance() rune {
+    if s.IsEnd() {
+        return 0
+    }
+
+    var ch = rune(s.Source[s.Current])
+    s.Current = s.Current + 1
+    return ch
+}
+
+func (s *Scanner) AddToken(token_type string) Token {
+    token := Token {
+        Line: s.StartLine,
+        Column: s.Start - s.StartLineStart + 1,
+        Start: s.Start,
+        End: s.Current,
+        Lexeme: s.String(),
+        Type: token_type,
+    }
+    s.Tokens = append(s.Tokens, token)
+    return token
+}
+
+func (s *Scanner) ScanToken() {
+    var c = s.Advance()
+    switch {
+    case c == '(':
+        s.AddToken(TK_LEFT_PAREN)
+    case c == ')':
+        s.AddToken(TK_RIGHT_PAREN)
+    case c == '{':
+        if n := len(s.Interpolations); n > 0 {
+            s.Interpolations[n-1]++
+        }
+        s.AddToken(TK_LEFT_BRACE)
+    case c == '}':
+        // the brace closing an interpolation resumes the string
+        if n := len(s.Interpolations); n > 0 {
+            if s.Interpolations[n-1] == 0 {
+                s.Interpolations = s.Interpolations[:n-1]
+                s.ScanString()
+                break
+            }
+            s.Interpolations[n-1]--
+        }
+        s.AddToken(TK_RIGHT_BRACE)
+    case c == '.':
+        s.AddToken(TK_DOT)
+    case c == '+':
+        s.AddToken(TK_PLUS)
+    case c == '-':
+        s.AddToken(TK_MINUS)
+    case c == '*':
+        if s.Match("*") {
+            s.AddToken(TK_STAR_STAR)
+        } else {
+            s.AddToken(TK_STAR)
+        }
+    case c == '%':
+        s.AddToken(TK_PERCENT)
+    case c == '~':
+        // the integer division is `~/`, as `//` starts a comment
+        if s.Match("/") {
+            s.AddToken(TK_TILDE_SLASH)
+        } else {
+            s.AddToken(TK_TILDE)
+        }
+    case c == '&':
+        s.AddToken(TK_AMPERSAND)
+    case c == '|':
+        s.AddToken(TK_PIPE)
+    case c == '^':
+        s.AddToken(TK_CARET)
+    case c == ',':
+        s.AddToken(TK_COMMA)
+    case c == ';':
+        s.AddToken(TK_SEMICOLON)
+    case c == ' ':
+    case c == '\r':
+    case c == '\t':
+        // Ingore white space
+    case c == '\n':
+        s.NewLine()
+    case c == '=':
+        if s.Match("=") {
+            s.AddToken(TK_EQUAL_EQUAL)
+        } else {
+            s.AddToken(TK_EQUAL)
+        }
+    case c == '!':
+        if s.Match("=") {
+            s.AddToken(TK_BANG_EQUAL)
+        } else {
+            s.AddToken(TK_BANG)
+        }
+    case c == '>':
+        if s.Match("=") {
+            s.AddToken(TK_GREATER_EQUAL)
+        } else if s.Match(">") {
+            s.AddToken(TK_GREATER_GREATER)
+        } else {
+            s.AddToken(TK_GREATER)
+        }
+    case c == '<':
+        if s.Match("=") {
+            s.AddToken(TK_LESS_EQUAL)
+        } else if s.Match("<") {
+            s.AddToken(TK_LESS_LESS)
+        } else {
+            s.AddToken(TK_LESS)
+        }
+    case c == '/':
+        if s.Match("/") {
+            s.SkipOneLineComment()
+        } else {
+            s.AddToken(TK_SLASH)
+        }
+    case c == '"':
+        s.ScanString()
+    case c >= '0' && c <= '9':
+        s.ResolveNum()
+    case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_':
+        s.ResolveIdAndKeyword()
+    default:
+        s.Report("Unexpected character: %c", c)
+    }
+}
+
+func (s *Scanner) ResolveIdAndKeyword() {
+    isIdChar := func (ch rune) bool {
+        return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '_'
+    }
+
+    for isIdChar(s.Peek()) {
+        s.Current++
+    }
+
+    if s.ReservedKws[s.String()] != "" {
+        s.AddToken(s.ReservedKws[s.String()])
+    } else {
+        s.AddToken(TK_IDENTIFIER)
+    }
+}
+
+
+func (s *Scanner) String() string {
+    return s.Source[s.Start : s.Current]
+}
+
+
+// the whole literal is scanned as one token even if it's malformed, such
+// as 0x1G or 1__0, so that the error is reported once where it is
+func (s *Scanner) ResolveNum() {
+    isDigit := func (ch rune) bool {
+        return ch >= '0' && ch <= '9'
+    }
+    isAlnum := func (ch rune) bool {
+        return isDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_'
+    }
+    isDecimal := !strings.HasPrefix(s.Source[s.Start:], "0x") && !strings.HasPrefix(s.Source[s.Start:], "0X")
+
+    for {
+        ch := s.Peek()
+        prev := rune(s.Source[s.Current-1])
+        if isAlnum(ch) {
+            s.Current++
+        } else if ch == '.' && isDigit(s.PeekNext()) {
+            s.Current++
+        } else if (ch == '+' || ch == '-') && (prev == 'e' || prev == 'E') && isDecimal && isDigit(s.PeekNext()) {
+            s.Current++
+        } else {
+            break
+        }
+    }
+
+    if _, err := ParseLiteral(s.String()); err != nil {
+        e := err.(*LiteralError)
+        s.ReportAt(s.Start + e.Start, s.Start + e.End, "%s", e.Message)
+    }
+
+    s.AddToken(TK_NUMBER)
+}
+
+func (s *Scanner) Peek() rune {
+    if s.IsEnd() {
+        return 0
+    }
+    return rune(s.Source[s.Current])
+}
+
+func (s *Scanner) PeekNext() rune {
+    if s.Current + 1 >= len(s.Source) {
+        return 0
+    }
+
+    return rune(s.Source[s.Current + 1])
+}
+
+// scan the rest of a string, after the opening quote or after the brace
+// closing an interpolation. the string ends at the closing quote, or at
+// the next interpolation:
+//   "Hello ${name}!" is scanned as INTERPOLATION, IDENTIFIER and STRING
+func (s *Scanner) ScanString() {
+    var text strings.Builder
+    for !s.IsEnd() {
+        c := s.Source[s.Current]
+        switch {
+        case c == '"':
+            s.Current++
+            s.AddStringToken(TK_STRING, text.String())
+            return
+        case c == '$' && s.PeekNext() == '{':
+            s.Current += 2
+            s.AddStringToken(TK_INTERPOLATION, text.String())
+            s.Interpolations = append(s.Interpolations, 0)
+            return
+        case c == '\\':
+            s.ScanEscape(&text)
+        case c == '\n':
+            s.Current++
+            s.NewLine()
+            text.WriteByte(c)
+        default:
+            s.Current++
+            text.WriteByte(c)
+        }
+    }
+
+    s.Report("Unterminated string.")
+}
+
+func (s *Scanner) AddStringToken(tokenType string, text string) {
+    s.AddToken(tokenType)
+    s.Tokens[len(s.Tokens) - 1].Text = text
+}
+
+// decode the escape sequence starting at the backslash
+func (s *Scanner) ScanEscape(text *strings.Builder) {
+    start := s.Current
+    s.Current++
+
+    switch c := s.Advance(); c {
+    case 'n':
+        text.WriteByte('\n')
+    case 't':
+        text.WriteByte('\t')
+    case 'r':
+        text.WriteByte('\r')
+    case '"', '\\', '$':
+        text.WriteRune(c)
+    case 'u':
+        // \u{1F600}, from 1 to 6 hexadecimal digits
+        if !s.Match("{") {
+            s.ReportAt(start, s.Current, "Invalid unicode escape sequence: expect '{' after '\\u'.")
+            return
+        }
+
+        digits := s.Current
+        for isHexDigit(s.Peek()) {
+            s.Current++
+        }
+        hex := s.Source[digits:s.Current]
+
+        if !s.Match("}") {
+            s.ReportAt(start, s.Current, "Invalid unicode escape sequence: expect '}' after the code point.")
+            return
+        }
+
+        code, err := strconv.ParseUint(hex, 16, 32)
+        if hex == "" || len(hex) > 6 || err != nil || !utf8.ValidRune(rune(code)) {
+            s.ReportAt(start, s.Current, "Invalid unicode code point: \\u{%s}.", hex)
+            return
+        }
+        text.WriteRune(rune(code))
+    case 0:
+        // the string is unterminated
+    case '\n':
+        s.NewLine()
+        s.ReportAt(start, start + 1, "Invalid escape sequence: backslash at end of line.")
+    default:
+        s.ReportAt(start, s.Current, "Invalid escape sequence: \\%c.", c)
+    }
+}
+
+func isHexDigit(ch rune) bool {
+    return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+func (s *Scanner) SkipOneLineComment() {
+    for !s.IsEnd() {
+        if s.Source[s.Current] == '\n' {
+            s.Current++ // Skip the newline
+            s.NewLine()
+            break
+        }
+        s.Current++
+    }
+}
+
+func (s *Scanner) Match(expect string) bool {
+    if s.Current + len(expect) > len(s.Source) {
+        return false
+    }
+
+    if expect == s.Source[s.Current : s.Current + len(expect)] {
+        s.Current += len(expect)
+        return true
+    }
+
+    return false
+}
+
+// called after the newline is consumed
+func (s *Scanner) NewLine() {
+    s.Line++
+    s.LineStart = s.Current
+}
+
+// report the error at the current token
+func (s *Scanner) Report(format string, args ...any) {
+    s.ReportAt(s.Start, s.Current, format, args...)
+}
+
+// report the error at the range of source, which may be in the middle of a token
+func (s *Scanner) ReportAt(start int, end int, format string, args ...any) {
+    line := 1 + strings.Count(s.Source[:start], "\n")
+    lineStart := strings.LastIndexByte(s.Source[:start], '\n') + 1
+
+    span := Span{Line: line, Column: start - lineStart + 1, Start: start, End: end}
+    msg := fmt.Sprintf("[line %d] Error: %s", line, fmt.Sprintf(format, args...))
+    s.Errors = append(s.Errors, &SpanError{Span: span, Message: msg})
+}
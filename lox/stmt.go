@@ -0,0 +1,367 @@
+package lox
+
+import (
+    "fmt"
+    "strings"
+)
+
+
+type Stmt interface {
+    // the String method is used to print the statement recursively.
+    // for example: (if (var a) (print 1.0))
+    String() string
+
+    // the Run method is used to execute the statement
+    Run(in *Interpreter) error
+
+    // the Span method returns the source range of the statement
+    Span() Span
+}
+
+
+type PrintStmt struct {
+    keyword *Token
+    v Expr
+}
+
+
+func (s PrintStmt) String() string {
+    return fmt.Sprintf("(print %s)", s.v)
+}
+
+
+func (s PrintStmt) Span() Span {
+    return s.keyword.Span().Merge(s.v.Span())
+}
+
+func (s PrintStmt) Run(in *Interpreter) error {
+    v, err := s.v.Eval(in)
+    if err != nil {
+        return err
+    }
+
+    fmt.Fprintln(in.stdout, v)
+    return nil
+}
+
+
+type ExprStmt struct {
+    v Expr
+}
+
+
+func (s ExprStmt) String() string {
+    return fmt.Sprintf("(; %s)", s.v)
+}
+
+
+func (s ExprStmt) Span() Span {
+    return s.v.Span()
+}
+
+func (s ExprStmt) Run(in *Interpreter) error {
+    _, err := s.v.Eval(in)
+    return err
+}
+
+
+type VarStmt struct {
+    v Expr
+    tk *Token
+}
+
+
+func (s VarStmt) String() string {
+    if s.v == nil {
+        return fmt.Sprintf("(var %s)", s.tk.Lexeme)
+    }
+
+    return fmt.Sprintf("(var %s = %s)", s.tk.Lexeme, s.v)
+}
+
+
+func (s VarStmt) Span() Span {
+    if s.v == nil {
+        return s.tk.Span()
+    }
+
+    return s.tk.Span().Merge(s.v.Span())
+}
+
+func (s VarStmt) Run(in *Interpreter) error {
+    var err error
+    var v ValueType
+
+    if s.v == nil {
+        v = NilValue
+    } else {
+        if v, err = s.v.Eval(in); err != nil {
+            return err
+        }
+    }
+
+    // store the variable in the innermost scope
+    in.environment.Define(s.tk.Lexeme, v)
+
+    return nil
+}
+
+type BlockStmt struct {
+    stmts []Stmt
+}
+
+func (s BlockStmt) String() string {
+    var sb strings.Builder
+    sb.WriteString("(block")
+    for _, stmt := range(s.stmts) {
+        sb.WriteString(" ")
+        sb.WriteString(stmt.String())
+    }
+    sb.WriteString(")")
+
+    return sb.String()
+}
+
+func (s BlockStmt) Span() Span {
+    var span Span
+    for _, stmt := range(s.stmts) {
+        span = span.Merge(stmt.Span())
+    }
+
+    return span
+}
+
+func (s BlockStmt) Run(in *Interpreter) error {
+    return in.ExecuteBlock(s.stmts, NewEnvironment(in.environment))
+}
+
+// execute the statements in the given scope. the previous scope is always
+// restored, even if one of the statements fails
+func (in *Interpreter) ExecuteBlock(stmts []Stmt, env *Environment) error {
+    previous := in.environment
+    in.environment = env
+    defer func() {
+        in.environment = previous
+    }()
+
+    for _, stmt := range(stmts) {
+        if err := stmt.Run(in); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+type IfStmt struct {
+    keyword *Token
+    cond Expr
+    then Stmt
+    otherwise Stmt // nil if there is no else branch
+}
+
+func (s IfStmt) String() string {
+    if s.otherwise == nil {
+        return fmt.Sprintf("(if %s %s)", s.cond, s.then)
+    }
+
+    return fmt.Sprintf("(if-else %s %s %s)", s.cond, s.then, s.otherwise)
+}
+
+func (s IfStmt) Span() Span {
+    span := s.keyword.Span().Merge(s.then.Span())
+    if s.otherwise != nil {
+        span = span.Merge(s.otherwise.Span())
+    }
+
+    return span
+}
+
+func (s IfStmt) Run(in *Interpreter) error {
+    cond, err := s.cond.Eval(in)
+    if err != nil {
+        return err
+    }
+
+    if IsTruthy(cond) {
+        return s.then.Run(in)
+    } else if s.otherwise != nil {
+        return s.otherwise.Run(in)
+    }
+
+    return nil
+}
+
+// While statement. the for loop is desugared into a while loop by parser
+type WhileStmt struct {
+    keyword *Token // the `for` keyword if it's desugared from a for loop
+    cond Expr
+    body Stmt
+}
+
+func (s WhileStmt) String() string {
+    return fmt.Sprintf("(while %s %s)", s.cond, s.body)
+}
+
+func (s WhileStmt) Span() Span {
+    return s.keyword.Span().Merge(s.body.Span())
+}
+
+func (s WhileStmt) Run(in *Interpreter) error {
+    for {
+        if err := in.Step(); err != nil {
+            return Locate(err, s.keyword, s.keyword.Span())
+        }
+
+        cond, err := s.cond.Eval(in)
+        if err != nil {
+            return err
+        }
+
+        if !IsTruthy(cond) {
+            return nil
+        }
+
+        if err = s.body.Run(in); err != nil {
+            return err
+        }
+    }
+}
+
+// Function declaration. for example: fun add(a, b) { return a + b; }
+type FunStmt struct {
+    name *Token
+    params []*Token
+    body []Stmt
+}
+
+func (s *FunStmt) String() string {
+    params := make([]string, 0, len(s.params))
+    for _, param := range(s.params) {
+        params = append(params, param.Lexeme)
+    }
+
+    return fmt.Sprintf("(fun %s (%s) %s)", s.name.Lexeme, strings.Join(params, " "), BlockStmt{stmts: s.body})
+}
+
+func (s *FunStmt) Span() Span {
+    return s.name.Span()
+}
+
+func (s *FunStmt) Run(in *Interpreter) error {
+    if err := in.Allocate(closureSize); err != nil {
+        return Locate(err, s.name, s.name.Span())
+    }
+
+    // the function captures the scope where it is declared
+    in.environment.Define(s.name.Lexeme, &FunctionType{decl: s, closure: in.environment})
+    return nil
+}
+
+// Class declaration. for example: class B < A { init() {} }
+type ClassStmt struct {
+    name *Token
+    superclass *VarExpr // nil if there is no superclass
+    methods []*FunStmt
+}
+
+func (s ClassStmt) String() string {
+    var sb strings.Builder
+    sb.WriteString("(class ")
+    sb.WriteString(s.name.Lexeme)
+    if s.superclass != nil {
+        sb.WriteString(" < ")
+        sb.WriteString(s.superclass.token.Lexeme)
+    }
+    for _, method := range(s.methods) {
+        sb.WriteString(" ")
+        sb.WriteString(method.String())
+    }
+    sb.WriteString(")")
+
+    return sb.String()
+}
+
+func (s ClassStmt) Span() Span {
+    return s.name.Span()
+}
+
+func (s ClassStmt) Run(in *Interpreter) error {
+    var superclass *ClassType
+    if s.superclass != nil {
+        v, err := s.superclass.Eval(in)
+        if err != nil {
+            return err
+        }
+
+        var ok bool
+        if superclass, ok = v.(*ClassType); !ok {
+            return Locate(fmt.Errorf("Superclass must be a class."), s.superclass.token, s.superclass.Span())
+        }
+    }
+
+    in.environment.Define(s.name.Lexeme, NilValue)
+
+    // the methods of a subclass capture an extra scope holding `super`
+    closure := in.environment
+    if superclass != nil {
+        closure = NewEnvironment(in.environment)
+        closure.Define("super", superclass)
+    }
+
+    methods := make(map[string]*FunctionType)
+    for _, method := range(s.methods) {
+        methods[method.name.Lexeme] = &FunctionType{
+            decl: method,
+            closure: closure,
+            isInitializer: method.name.Lexeme == "init",
+        }
+    }
+
+    class := &ClassType{name: s.name.Lexeme, superclass: superclass, methods: methods}
+    return in.environment.Assign(s.name, class)
+}
+
+type ReturnStmt struct {
+    keyword *Token
+    v Expr // nil if there is no return value
+}
+
+func (s ReturnStmt) String() string {
+    if s.v == nil {
+        return "(return)"
+    }
+
+    return fmt.Sprintf("(return %s)", s.v)
+}
+
+func (s ReturnStmt) Span() Span {
+    if s.v == nil {
+        return s.keyword.Span()
+    }
+
+    return s.keyword.Span().Merge(s.v.Span())
+}
+
+func (s ReturnStmt) Run(in *Interpreter) error {
+    var v ValueType = NilValue
+    if s.v != nil {
+        var err error
+        if v, err = s.v.Eval(in); err != nil {
+            return err
+        }
+    }
+
+    // unwind the statements up to the function call
+    return &ReturnSignal{v: v}
+}
+
+// ReturnSignal is not a real error. it carries the return value through
+// the Run methods until it's caught by the function call
+type ReturnSignal struct {
+    v ValueType
+}
+
+func (r *ReturnSignal) Error() string {
+    return "Can't return from top-level code."
+}
@@ -1,8 +1,8 @@
-package main
+package lox
 
 import (
     "fmt"
-    "strconv"
+    "strings"
 )
 
 
@@ -12,6 +12,7 @@ const (
     TK_LEFT_BRACE = "LEFT_BRACE"      // {
     TK_RIGHT_BRACE = "RIGHT_BRACE"    // }
     TK_STAR = "STAR"                  // *
+    TK_STAR_STAR = "STAR_STAR"        // **
     TK_DOT = "DOT"                    // .
     TK_COMMA = "COMMA"                // ,
     TK_PLUS = "PLUS"                  // +
@@ -26,7 +27,16 @@ const (
     TK_GREATER = "GREATER"            // >
     TK_GREATER_EQUAL = "GREATER_EQUAL"// >=
     TK_SLASH = "SLASH"                // /
+    TK_TILDE_SLASH = "TILDE_SLASH"    // ~/
+    TK_PERCENT = "PERCENT"            // %
+    TK_AMPERSAND = "AMPERSAND"        // &
+    TK_PIPE = "PIPE"                  // |
+    TK_CARET = "CARET"                // ^
+    TK_TILDE = "TILDE"                // ~
+    TK_LESS_LESS = "LESS_LESS"        // <<
+    TK_GREATER_GREATER = "GREATER_GREATER" // >>
     TK_STRING = "STRING"              // "abc"
+    TK_INTERPOLATION = "INTERPOLATION"// "abc ${ or } abc ${, followed by an expression
     TK_NUMBER = "NUMBER"              // 3.14
     TK_IDENTIFIER = "IDENTIFIER"      // abc
     TK_EOF = "EOF"                    // EOF
@@ -52,8 +62,14 @@ const (
 
 type Token struct {
     Line int
+    Column int // 1-based column of the first character
+    Start int  // byte offset of the first character in source
+    End int    // byte offset after the last character
     Lexeme string
     Type string
+
+    // the content of a string, with the escape sequences decoded
+    Text string
 }
 
 func NewToken(typ string, lexeme string, line int) Token {
@@ -64,15 +80,53 @@ func NewToken(typ string, lexeme string, line int) Token {
     }
 }
 
+// the source range of the token. the tokens made up by the parser and the
+// virtual machine only have a line
+func (t *Token) Span() Span {
+    return Span{Line: t.Line, Column: t.Column, Start: t.Start, End: t.End}
+}
+
+// Span is a range of source, used to underline the location of errors
+type Span struct {
+    Line int
+    Column int
+    Start int
+    End int
+}
+
+func (s Span) IsZero() bool {
+    return s.Line == 0
+}
+
+// the smallest span covering both spans
+func (s Span) Merge(other Span) Span {
+    if s.IsZero() {
+        return other
+    }
+    if other.IsZero() {
+        return s
+    }
+
+    res := s
+    if other.Start < s.Start {
+        res.Line, res.Column, res.Start = other.Line, other.Column, other.Start
+    }
+    if other.End > res.End {
+        res.End = other.End
+    }
+
+    return res
+}
+
 func (t Token) Literal() (ValueType, error) {
     switch (t.Type) {
-    case TK_STRING:
-        return StringType{v: t.Lexeme[1:len(t.Lexeme)-1]}, nil
+    case TK_STRING, TK_INTERPOLATION:
+        return StringType{v: t.Text}, nil
     case TK_NUMBER:
-        if v, err := strconv.ParseFloat(t.Lexeme, 64); err != nil {
+        if v, err := ParseLiteral(t.Lexeme); err != nil {
             return NilValue, nil
         } else {
-            return NumberType{v: v}, nil
+            return v, nil
         }
     case KW_FALSE:
         return FalseValue, nil
@@ -86,17 +140,24 @@ func (t Token) Literal() (ValueType, error) {
 }
 
 func (t Token) LiteralString() string {
-    if t.Type == TK_STRING {
-        return t.Lexeme[1:len(t.Lexeme)-1]
+    if t.Type == TK_STRING || t.Type == TK_INTERPOLATION {
+        return t.Text
     }
 
+    // the literals are printed in the canonical format, except that the
+    // integral ones keep a fraction: 42.0. the big integers and decimals
+    // are printed as they are
     if t.Type == TK_NUMBER {
-        num, _ := strconv.ParseFloat(t.Lexeme, 64)
-        if num == float64(int(num)) {
-            return fmt.Sprintf("%.1f", num)
-        } else {
-            return fmt.Sprintf("%v", num)
+        if v, err := ParseLiteral(t.Lexeme); err == nil && IsExact(v) {
+            return v.String()
+        }
+
+        num, _ := ParseNumber(t.Lexeme)
+        s := FormatNumber(num)
+        if !strings.ContainsAny(s, ".en") {
+            s += ".0"
         }
+        return s
     }
 
     return "null"
@@ -0,0 +1,505 @@
+package lox
+
+import (
+    "fmt"
+    "math"
+    "math/big"
+    "strconv"
+    "strings"
+)
+
+var VT_Nil = "nil"
+var VT_Bool = "bool"
+var VT_String = "string"
+var VT_Number = "number"
+var VT_Int = "int"
+var VT_BigInt = "bigint"
+var VT_Decimal = "decimal"
+var VT_Function = "function"
+var VT_Class = "class"
+var VT_Instance = "instance"
+
+// the maximum number of parameters and arguments of a function
+const MaxArity = 255
+
+type ValueType interface {
+    String() string
+    Literal() any
+    Type() string
+    IsTrue() bool
+}
+
+// the constructors of the values passed between Go and Lox. the values
+// can be converted back with Literal()
+func NewNil() ValueType {
+    return NilType{}
+}
+
+func NewBool(v bool) ValueType {
+    return BoolType{v: v}
+}
+
+func NewNumber(v float64) ValueType {
+    return NumberType{v: v}
+}
+
+func NewInt(v int64) ValueType {
+    return IntType{v: v}
+}
+
+func NewBigInt(v *big.Int) ValueType {
+    return BigIntType{v: new(big.Int).Set(v)}
+}
+
+func NewDecimal(v *big.Rat) ValueType {
+    return DecimalType{v: new(big.Rat).Set(v)}
+}
+
+func NewString(v string) ValueType {
+    return StringType{v: v}
+}
+
+type NilType struct {
+    v ValueType // v is nil by default
+}
+
+func (t NilType) String() string {
+    return "nil"
+}
+
+func (t NilType) Literal() any {
+    return nil
+}
+
+func (t NilType) Type() string {
+    return "nil"
+}
+
+func (t NilType) IsTrue() bool {
+    return false
+}
+
+type BoolType struct {
+    v bool
+}
+
+func (t BoolType) String() string {
+    if t.v {
+        return "true"
+    } else {
+        return "false"
+    }
+}
+
+func (t BoolType) Literal() any {
+    return t.v
+}
+
+func (t BoolType) Type() string {
+    return "bool"
+}
+
+func (t BoolType) IsTrue() bool {
+    return t.v
+}
+
+type StringType struct {
+    v string
+}
+
+func (t StringType) String() string {
+    return t.v
+}
+
+func (t StringType) Literal() any {
+    return t.v
+}
+
+func (t StringType) Type() string {
+    return "string"
+}
+
+func (t StringType) IsTrue() bool {
+    return len(t.v) > 0
+}
+
+type NumberType struct {
+    v float64
+}
+
+func (t NumberType) String() string {
+    return FormatNumber(t.v)
+}
+
+// the canonical format of numbers, the one printed by the programs. the
+// integral numbers have no fraction, and the very large or small numbers
+// use the exponent: 3, 3.5, -0, 1e+21, 1e-7, nan, inf
+func FormatNumber(v float64) string {
+    switch {
+    case math.IsNaN(v):
+        return "nan"
+    case math.IsInf(v, 1):
+        return "inf"
+    case math.IsInf(v, -1):
+        return "-inf"
+    }
+
+    if abs := math.Abs(v); abs == 0 || abs >= 1e-6 && abs < 1e21 {
+        return strconv.FormatFloat(v, 'f', -1, 64)
+    }
+
+    // the exponent is not padded: 1e-7 rather than 1e-07
+    s := strconv.FormatFloat(v, 'g', -1, 64)
+    mantissa, exponent, _ := strings.Cut(s, "e")
+    sign, digits := exponent[:1], strings.TrimLeft(exponent[1:], "0")
+    return mantissa + "e" + sign + digits
+}
+
+func (t NumberType) Literal() any {
+    return t.v
+}
+
+func (t NumberType) Type() string {
+    return "number"
+}
+
+func (t NumberType) IsTrue() bool {
+    return t.v != 0
+}
+
+// IntType is an integer, exact up to 64 bits. the literals without fraction
+// and exponent are integers, and the integers are promoted to numbers when
+// mixed with them
+type IntType struct {
+    v int64
+}
+
+func (t IntType) String() string {
+    return strconv.FormatInt(t.v, 10)
+}
+
+func (t IntType) Literal() any {
+    return t.v
+}
+
+func (t IntType) Type() string {
+    return "int"
+}
+
+func (t IntType) IsTrue() bool {
+    return t.v != 0
+}
+
+// the number of the integer, rounded to the nearest float if it's too large
+func (t IntType) Float() NumberType {
+    return NumberType{v: float64(t.v)}
+}
+
+func (t IntType) Big() BigIntType {
+    return BigIntType{v: big.NewInt(t.v)}
+}
+
+func (t IntType) Decimal() DecimalType {
+    return DecimalType{v: new(big.Rat).SetInt64(t.v)}
+}
+
+// BigIntType is an integer of arbitrary precision, written with the n
+// suffix: 123n. the big.Int is never modified once wrapped
+type BigIntType struct {
+    v *big.Int
+}
+
+func (t BigIntType) String() string {
+    return t.v.String()
+}
+
+func (t BigIntType) Literal() any {
+    return new(big.Int).Set(t.v)
+}
+
+func (t BigIntType) Type() string {
+    return "bigint"
+}
+
+func (t BigIntType) IsTrue() bool {
+    return t.v.Sign() != 0
+}
+
+func (t BigIntType) Decimal() DecimalType {
+    return DecimalType{v: new(big.Rat).SetInt(t.v)}
+}
+
+// DecimalType is an exact rational number, written with the d suffix:
+// 1.10d. the money amounts are kept exactly, 0.1d + 0.2d == 0.3d. the
+// big.Rat is never modified once wrapped
+type DecimalType struct {
+    v *big.Rat
+}
+
+func (t DecimalType) String() string {
+    return FormatDecimal(t.v)
+}
+
+func (t DecimalType) Literal() any {
+    return new(big.Rat).Set(t.v)
+}
+
+func (t DecimalType) Type() string {
+    return "decimal"
+}
+
+func (t DecimalType) IsTrue() bool {
+    return t.v.Sign() != 0
+}
+
+// the integer value of an integer or an integral number in range
+func AsInt(v ValueType) (int64, bool) {
+    switch t := v.(type) {
+    case IntType:
+        return t.v, true
+    case NumberType:
+        if t.v == math.Trunc(t.v) && t.v >= math.MinInt64 && t.v < math.MaxInt64 {
+            return int64(t.v), true
+        }
+    }
+
+    return 0, false
+}
+
+// Callable is implemented by the values that can be called, such as functions
+type Callable interface {
+    Arity() int
+    Call(in *Interpreter, args []ValueType) (ValueType, error)
+}
+
+// FunctionType is a user-defined function. it captures the environment where
+// the function is declared, so that it can be used as a closure
+type FunctionType struct {
+    decl *FunStmt
+    closure *Environment
+    isInitializer bool // the init method always returns `this`
+}
+
+func (t *FunctionType) String() string {
+    return fmt.Sprintf("<fn %s>", t.decl.name.Lexeme)
+}
+
+func (t *FunctionType) Literal() any {
+    return t
+}
+
+func (t *FunctionType) Type() string {
+    return "function"
+}
+
+func (t *FunctionType) IsTrue() bool {
+    return true
+}
+
+func (t *FunctionType) Arity() int {
+    return len(t.decl.params)
+}
+
+func (t *FunctionType) Call(in *Interpreter, args []ValueType) (ValueType, error) {
+    env := NewEnvironment(t.closure)
+    for i, param := range(t.decl.params) {
+        env.Define(param.Lexeme, args[i])
+    }
+
+    if err := in.ExecuteBlock(t.decl.body, env); err != nil {
+        ret, ok := err.(*ReturnSignal)
+        if !ok {
+            if rt, ok := err.(*RuntimeError); ok {
+                rt.PushFrame(t.decl.name.Lexeme, in.file)
+            }
+            return nil, err
+        }
+        if !t.isInitializer {
+            return ret.v, nil
+        }
+    }
+
+    if t.isInitializer {
+        return t.closure.values["this"], nil
+    }
+
+    return NilValue, nil
+}
+
+// bind the method to an instance, `this` is defined in a new scope
+// between the method and its closure
+func (t *FunctionType) Bind(instance *InstanceType) *FunctionType {
+    env := NewEnvironment(t.closure)
+    env.Define("this", instance)
+    return &FunctionType{decl: t.decl, closure: env, isInitializer: t.isInitializer}
+}
+
+// ClassType is a class declaration, calling it creates a new instance
+type ClassType struct {
+    name string
+    superclass *ClassType
+    methods map[string]*FunctionType
+}
+
+func (t *ClassType) String() string {
+    return t.name
+}
+
+func (t *ClassType) Literal() any {
+    return t
+}
+
+func (t *ClassType) Type() string {
+    return "class"
+}
+
+func (t *ClassType) IsTrue() bool {
+    return true
+}
+
+// find the method in the class or its superclasses
+func (t *ClassType) FindMethod(name string) *FunctionType {
+    for class := t; class != nil; class = class.superclass {
+        if method, ok := class.methods[name]; ok {
+            return method
+        }
+    }
+
+    return nil
+}
+
+// the arity of a class is the arity of its initializer
+func (t *ClassType) Arity() int {
+    if init := t.FindMethod("init"); init != nil {
+        return init.Arity()
+    }
+
+    return 0
+}
+
+func (t *ClassType) Call(in *Interpreter, args []ValueType) (ValueType, error) {
+    if err := in.Allocate(instanceSize); err != nil {
+        return nil, err
+    }
+
+    instance := &InstanceType{class: t, fields: make(map[string]ValueType)}
+    if init := t.FindMethod("init"); init != nil {
+        if _, err := init.Bind(instance).Call(in, args); err != nil {
+            return nil, err
+        }
+    }
+
+    return instance, nil
+}
+
+// InstanceType is an instance of class
+type InstanceType struct {
+    class *ClassType
+    fields map[string]ValueType
+}
+
+func (t *InstanceType) String() string {
+    return t.class.name + " instance"
+}
+
+func (t *InstanceType) Literal() any {
+    return t
+}
+
+func (t *InstanceType) Type() string {
+    return "instance"
+}
+
+func (t *InstanceType) IsTrue() bool {
+    return true
+}
+
+// the fields shadow the methods
+func (t *InstanceType) Get(name *Token) (ValueType, error) {
+    if v, ok := t.fields[name.Lexeme]; ok {
+        return v, nil
+    }
+
+    if method := t.class.FindMethod(name.Lexeme); method != nil {
+        return method.Bind(t), nil
+    }
+
+    return nil, fmt.Errorf("Undefined property '%s'.", name.Lexeme)
+}
+
+func (t *InstanceType) Set(name *Token, v ValueType) {
+    t.fields[name.Lexeme] = v
+}
+
+// NativeFn is the signature of the functions implemented in go. the
+// arguments are already checked against the arity, and the returned error
+// is raised as a runtime error at the call
+type NativeFn func(args []ValueType) (ValueType, error)
+
+// NativeFunction is a function implemented in go, such as clock()
+type NativeFunction struct {
+    name string
+    arity int
+    fn NativeFn
+}
+
+func NewNativeFunction(name string, arity int, fn NativeFn) *NativeFunction {
+    return &NativeFunction {
+        name: name,
+        arity: arity,
+        fn: fn,
+    }
+}
+
+func (t *NativeFunction) Name() string {
+    return t.name
+}
+
+func (t *NativeFunction) String() string {
+    return "<native fn>"
+}
+
+func (t *NativeFunction) Literal() any {
+    return t
+}
+
+func (t *NativeFunction) Type() string {
+    return "function"
+}
+
+func (t *NativeFunction) IsTrue() bool {
+    return true
+}
+
+func (t *NativeFunction) Arity() int {
+    return t.arity
+}
+
+func (t *NativeFunction) Call(in *Interpreter, args []ValueType) (ValueType, error) {
+    v, err := t.fn(args)
+    if err != nil {
+        return nil, err
+    }
+
+    // a go function returning nothing returns nil to the script
+    if v == nil {
+        return NilValue, nil
+    }
+
+    return v, nil
+}
+
+// the values compared by identity rather than by content
+func IsReference(v ValueType) bool {
+    switch v.(type) {
+    case NilType, BoolType, StringType, NumberType, IntType, BigIntType, DecimalType:
+        return false
+    }
+    return true
+}
+
+var NilValue = NilType{}
+var TrueValue = BoolType{v: true}
+var FalseValue = BoolType{v: false}
+var EmptyStringValue = StringType{v: ""}
+var ZeroNumberValue = NumberType{v: 0}
@@ -0,0 +1,579 @@
+package lox
+
+import (
+    "fmt"
+    "math"
+)
+
+// CallFrame is an ongoing function call. the slots of the frame start at
+// base, where the slot zero holds the callee or `this`
+type CallFrame struct {
+    closure *ClosureObj
+    ip int
+    base int
+}
+
+// VM is a stack-based virtual machine running the compiled bytecode
+type VM struct {
+    in *Interpreter
+
+    stack []ValueType
+    frames []*CallFrame
+    globals map[string]ValueType
+
+    // the open upvalues pointing to the stack, ordered by slot descending
+    openUpvalues *UpvalueObj
+
+    // the value returned by the top-level function, see Evaluate
+    result ValueType
+}
+
+// the global variables are shared with the interpreter, so that the host
+// sees the same globals whichever backend runs the program
+func NewVM(in *Interpreter) *VM {
+    return &VM {
+        in: in,
+        stack: make([]ValueType, 0, 256),
+        frames: make([]*CallFrame, 0, 64),
+        globals: in.globals.values,
+    }
+}
+
+func (vm *VM) Interpret(fn *FunctionObj) error {
+    closure := &ClosureObj{fn: fn}
+    vm.Push(closure)
+    if err := vm.CallClosure(closure, 0); err != nil {
+        return err
+    }
+
+    return vm.Run()
+}
+
+// run the function compiled by CompileExpression and return its value
+func (vm *VM) Evaluate(fn *FunctionObj) (ValueType, error) {
+    if err := vm.Interpret(fn); err != nil {
+        return nil, err
+    }
+
+    return vm.result, nil
+}
+
+func (vm *VM) Push(v ValueType) {
+    vm.stack = append(vm.stack, v)
+}
+
+func (vm *VM) Pop() ValueType {
+    v := vm.stack[len(vm.stack)-1]
+    vm.stack = vm.stack[:len(vm.stack)-1]
+    return v
+}
+
+func (vm *VM) PeekValue(distance int) ValueType {
+    return vm.stack[len(vm.stack)-1-distance]
+}
+
+func (vm *VM) ResetStack() {
+    vm.stack = vm.stack[:0]
+    vm.frames = vm.frames[:0]
+    vm.openUpvalues = nil
+}
+
+// the error is located at the token and the span of the current instruction
+func (vm *VM) RuntimeError(format string, args ...any) error {
+    if len(vm.frames) == 0 {
+        return vm.Unwind(NewRuntimeError(nil, Span{}, fmt.Sprintf(format, args...)))
+    }
+
+    token, span := vm.CurrentLocation()
+    return vm.Unwind(NewRuntimeError(token, span, fmt.Sprintf(format, args...)))
+}
+
+// raise the go error at the current instruction, the same as Locate does
+func (vm *VM) Raise(err error) error {
+    if rt, ok := err.(*RuntimeError); ok {
+        return vm.Unwind(rt)
+    }
+
+    token, span := vm.CurrentLocation()
+    rt := NewRuntimeError(token, span, err.Error())
+    rt.Err = err
+    return vm.Unwind(rt)
+}
+
+// raise the go error at the token of the current instruction rather than
+// at its span, such as an undefined property at its name
+func (vm *VM) RaiseAtToken(err error) error {
+    token, _ := vm.CurrentLocation()
+    rt := NewRuntimeError(token, token.Span(), err.Error())
+    rt.Err = err
+    return vm.Unwind(rt)
+}
+
+// record the call stack into the error, and abort the execution
+func (vm *VM) Unwind(err *RuntimeError) error {
+    // walk the call stack from the innermost frame
+    for i := len(vm.frames) - 1; i >= 0; i-- {
+        frame := vm.frames[i]
+        err.line = frame.closure.fn.chunk.Lines[frame.ip - 1]
+        err.PushFrame(frame.closure.fn.name, vm.in.file)
+    }
+
+    vm.ResetStack()
+    return err
+}
+
+// the source line of the instruction being executed
+func (vm *VM) CurrentLine() int {
+    frame := vm.frames[len(vm.frames)-1]
+    return frame.closure.fn.chunk.Lines[frame.ip - 1]
+}
+
+// the token and the span the errors of the instruction being executed are
+// located at, see Compiler.SetLocation
+func (vm *VM) CurrentLocation() (*Token, Span) {
+    frame := vm.frames[len(vm.frames)-1]
+    chunk := frame.closure.fn.chunk
+    return chunk.Tokens[frame.ip - 1], chunk.Spans[frame.ip - 1]
+}
+
+func (vm *VM) CallClosure(closure *ClosureObj, argc int) error {
+    if argc != closure.fn.arity {
+        return vm.RuntimeError("Expected %d arguments but got %d.", closure.fn.arity, argc)
+    }
+
+    // the frame of the script is not a call
+    if len(vm.frames) > vm.in.MaxCallDepth() {
+        return vm.Raise(ErrStackOverflow)
+    }
+
+    vm.frames = append(vm.frames, &CallFrame{
+        closure: closure,
+        ip: 0,
+        base: len(vm.stack) - argc - 1,
+    })
+
+    return nil
+}
+
+func (vm *VM) CallValue(callee ValueType, argc int) error {
+    switch c := callee.(type) {
+    case *ClosureObj:
+        return vm.CallClosure(c, argc)
+    case *BoundMethodObj:
+        vm.stack[len(vm.stack)-argc-1] = c.receiver
+        return vm.CallClosure(c.method, argc)
+    case *ClassObj:
+        if err := vm.in.Allocate(instanceSize); err != nil {
+            return vm.Raise(err)
+        }
+        vm.stack[len(vm.stack)-argc-1] = &InstanceObj{class: c, fields: make(map[string]ValueType)}
+        if init, ok := c.methods["init"]; ok {
+            return vm.CallClosure(init, argc)
+        }
+        if argc != 0 {
+            return vm.RuntimeError("Expected 0 arguments but got %d.", argc)
+        }
+        return nil
+    case Callable:
+        if argc != c.Arity() {
+            return vm.RuntimeError("Expected %d arguments but got %d.", c.Arity(), argc)
+        }
+
+        args := make([]ValueType, argc)
+        copy(args, vm.stack[len(vm.stack)-argc:])
+
+        res, err := c.Call(vm.in, args)
+        if err != nil {
+            return vm.Raise(err)
+        }
+
+        vm.stack = vm.stack[:len(vm.stack)-argc-1]
+        vm.Push(res)
+        return nil
+    }
+
+    return vm.RuntimeError("Can only call functions and classes.")
+}
+
+func (vm *VM) CaptureUpvalue(slot int) *UpvalueObj {
+    var prev *UpvalueObj
+    upvalue := vm.openUpvalues
+    for upvalue != nil && upvalue.slot > slot {
+        prev = upvalue
+        upvalue = upvalue.next
+    }
+
+    if upvalue != nil && upvalue.slot == slot {
+        return upvalue
+    }
+
+    created := &UpvalueObj{slot: slot, next: upvalue}
+    if prev == nil {
+        vm.openUpvalues = created
+    } else {
+        prev.next = created
+    }
+
+    return created
+}
+
+// close the upvalues pointing to the slots at or above last
+func (vm *VM) CloseUpvalues(last int) {
+    for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+        upvalue := vm.openUpvalues
+        upvalue.closed = vm.stack[upvalue.slot]
+        upvalue.isClosed = true
+        vm.openUpvalues = upvalue.next
+    }
+}
+
+func (vm *VM) GetUpvalue(upvalue *UpvalueObj) ValueType {
+    if upvalue.isClosed {
+        return upvalue.closed
+    }
+
+    return vm.stack[upvalue.slot]
+}
+
+func (vm *VM) SetUpvalue(upvalue *UpvalueObj, v ValueType) {
+    if upvalue.isClosed {
+        upvalue.closed = v
+    } else {
+        vm.stack[upvalue.slot] = v
+    }
+}
+
+func (vm *VM) BindMethod(class *ClassObj, receiver ValueType, name string) (ValueType, error) {
+    method, ok := class.methods[name]
+    if !ok {
+        return nil, vm.RaiseAtToken(fmt.Errorf("Undefined property '%s'.", name))
+    }
+
+    return &BoundMethodObj{receiver: receiver, method: method}, nil
+}
+
+func (vm *VM) BinaryOp(op byte) error {
+    rhs := vm.Pop()
+    lhs := vm.Pop()
+
+    // fast path: both operands are numbers
+    if x, ok := lhs.(NumberType); ok {
+        if y, ok := rhs.(NumberType); ok {
+            switch op {
+            case OP_ADD:
+                vm.Push(NumberType{v: x.v + y.v})
+            case OP_SUBTRACT:
+                vm.Push(NumberType{v: x.v - y.v})
+            case OP_MULTIPLY:
+                vm.Push(NumberType{v: x.v * y.v})
+            case OP_DIVIDE:
+                vm.Push(NumberType{v: x.v / y.v})
+            case OP_INT_DIVIDE:
+                vm.Push(NumberType{v: FloorDiv(x.v, y.v)})
+            case OP_MODULO:
+                vm.Push(NumberType{v: FloorMod(x.v, y.v)})
+            case OP_EQUAL:
+                vm.Push(BoolType{v: x.v == y.v})
+            case OP_NOT_EQUAL:
+                vm.Push(BoolType{v: x.v != y.v})
+            case OP_GREATER:
+                vm.Push(BoolType{v: x.v > y.v})
+            case OP_GREATER_EQUAL:
+                vm.Push(BoolType{v: x.v >= y.v})
+            case OP_LESS:
+                vm.Push(BoolType{v: x.v < y.v})
+            case OP_LESS_EQUAL:
+                vm.Push(BoolType{v: x.v <= y.v})
+            case OP_POWER:
+                vm.Push(NumberType{v: math.Pow(x.v, y.v)})
+            default:
+                // the bitwise operations check the numbers are integral
+                return vm.SlowBinaryOp(lhs, rhs)
+            }
+            return nil
+        }
+    }
+
+    // fast path: both operands are integers, and the operation can't fail
+    if x, ok := lhs.(IntType); ok {
+        if y, ok := rhs.(IntType); ok {
+            switch op {
+            case OP_ADD:
+                if v, ok := AddInt(x.v, y.v); ok {
+                    vm.Push(IntType{v: v})
+                    return nil
+                }
+            case OP_SUBTRACT:
+                if v, ok := SubInt(x.v, y.v); ok {
+                    vm.Push(IntType{v: v})
+                    return nil
+                }
+            case OP_EQUAL:
+                vm.Push(BoolType{v: x.v == y.v})
+                return nil
+            case OP_NOT_EQUAL:
+                vm.Push(BoolType{v: x.v != y.v})
+                return nil
+            case OP_GREATER:
+                vm.Push(BoolType{v: x.v > y.v})
+                return nil
+            case OP_GREATER_EQUAL:
+                vm.Push(BoolType{v: x.v >= y.v})
+                return nil
+            case OP_LESS:
+                vm.Push(BoolType{v: x.v < y.v})
+                return nil
+            case OP_LESS_EQUAL:
+                vm.Push(BoolType{v: x.v <= y.v})
+                return nil
+            }
+        }
+    }
+
+    return vm.SlowBinaryOp(lhs, rhs)
+}
+
+// evaluate the binary operation the same as the interpreter does, the
+// instruction is located at the token of its operator
+func (vm *VM) SlowBinaryOp(lhs, rhs ValueType) error {
+    optr, _ := vm.CurrentLocation()
+
+    res, err := EvalBinary(optr, lhs, rhs)
+    if err != nil {
+        return vm.Raise(err)
+    }
+
+    if s, ok := res.(StringType); ok {
+        if err := vm.in.Allocate(len(s.v)); err != nil {
+            return vm.Raise(err)
+        }
+    }
+
+    vm.Push(res)
+    return nil
+}
+
+func (vm *VM) Run() error {
+    frame := vm.frames[len(vm.frames)-1]
+    chunk := frame.closure.fn.chunk
+
+    readByte := func() byte {
+        b := chunk.Code[frame.ip]
+        frame.ip++
+        return b
+    }
+
+    readShort := func() int {
+        v := chunk.ReadShort(frame.ip)
+        frame.ip += 2
+        return v
+    }
+
+    readString := func() string {
+        return chunk.Constants[readShort()].(StringType).v
+    }
+
+    for {
+        switch op := readByte(); op {
+        case OP_CONSTANT:
+            vm.Push(chunk.Constants[readShort()])
+        case OP_NIL:
+            vm.Push(NilValue)
+        case OP_TRUE:
+            vm.Push(TrueValue)
+        case OP_FALSE:
+            vm.Push(FalseValue)
+        case OP_POP:
+            vm.Pop()
+        case OP_GET_LOCAL:
+            vm.Push(vm.stack[frame.base + int(readByte())])
+        case OP_SET_LOCAL:
+            vm.stack[frame.base + int(readByte())] = vm.PeekValue(0)
+        case OP_GET_GLOBAL:
+            name := readString()
+            v, ok := vm.globals[name]
+            if !ok {
+                return vm.RuntimeError("Undefined variable '%s'.", name)
+            }
+            vm.Push(v)
+        case OP_DEFINE_GLOBAL:
+            vm.globals[readString()] = vm.Pop()
+        case OP_SET_GLOBAL:
+            name := readString()
+            if _, ok := vm.globals[name]; !ok {
+                return vm.RuntimeError("Undefined variable '%s'.", name)
+            }
+            vm.globals[name] = vm.PeekValue(0)
+        case OP_GET_UPVALUE:
+            vm.Push(vm.GetUpvalue(frame.closure.upvalues[readByte()]))
+        case OP_SET_UPVALUE:
+            vm.SetUpvalue(frame.closure.upvalues[readByte()], vm.PeekValue(0))
+        case OP_GET_PROPERTY:
+            name := readString()
+            // the instances made by the host, see ToValue
+            if host, ok := vm.PeekValue(0).(*InstanceType); ok {
+                v, err := host.Get(&Token{Type: TK_IDENTIFIER, Lexeme: name})
+                if err != nil {
+                    return vm.RaiseAtToken(err)
+                }
+                vm.stack[len(vm.stack)-1] = v
+                break
+            }
+
+            instance, ok := vm.PeekValue(0).(*InstanceObj)
+            if !ok {
+                return vm.RuntimeError("Only instances have properties.")
+            }
+
+            // the fields shadow the methods
+            if v, ok := instance.fields[name]; ok {
+                vm.stack[len(vm.stack)-1] = v
+                break
+            }
+
+            method, err := vm.BindMethod(instance.class, instance, name)
+            if err != nil {
+                return err
+            }
+            vm.stack[len(vm.stack)-1] = method
+        case OP_SET_PROPERTY:
+            name := readString()
+            fields, ok := instanceFields(vm.PeekValue(1))
+            if !ok {
+                return vm.RuntimeError("Only instances have fields.")
+            }
+
+            if _, ok := fields[name]; !ok {
+                if err := vm.in.Allocate(fieldSize); err != nil {
+                    return vm.Raise(err)
+                }
+            }
+
+            v := vm.Pop()
+            fields[name] = v
+            vm.stack[len(vm.stack)-1] = v
+        case OP_GET_SUPER:
+            name := readString()
+            superclass := vm.Pop().(*ClassObj)
+            method, err := vm.BindMethod(superclass, vm.PeekValue(0), name)
+            if err != nil {
+                return err
+            }
+            vm.stack[len(vm.stack)-1] = method
+        case OP_EQUAL, OP_NOT_EQUAL, OP_GREATER, OP_GREATER_EQUAL, OP_LESS, OP_LESS_EQUAL,
+            OP_ADD, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE, OP_INT_DIVIDE, OP_MODULO, OP_POWER,
+            OP_BIT_AND, OP_BIT_OR, OP_BIT_XOR, OP_SHIFT_LEFT, OP_SHIFT_RIGHT:
+            if err := vm.BinaryOp(op); err != nil {
+                return err
+            }
+        case OP_STRINGIFY:
+            vm.stack[len(vm.stack)-1] = Stringify(vm.PeekValue(0))
+        case OP_NOT:
+            vm.stack[len(vm.stack)-1] = BoolType{v: !IsTruthy(vm.PeekValue(0))}
+        case OP_NEGATE:
+            if v, ok := vm.PeekValue(0).(NumberType); ok {
+                vm.stack[len(vm.stack)-1] = NumberType{v: -v.v}
+                break
+            }
+            if v, ok := vm.PeekValue(0).(IntType); ok && v.v != math.MinInt64 {
+                vm.stack[len(vm.stack)-1] = IntType{v: -v.v}
+                break
+            }
+
+            optr, _ := vm.CurrentLocation()
+            res, err := EvalUnary(optr, vm.Pop())
+            if err != nil {
+                return vm.Raise(err)
+            }
+            vm.Push(res)
+        case OP_BIT_NOT:
+            optr, _ := vm.CurrentLocation()
+            res, err := EvalUnary(optr, vm.Pop())
+            if err != nil {
+                return vm.Raise(err)
+            }
+            vm.Push(res)
+        case OP_PRINT:
+            fmt.Fprintln(vm.in.stdout, vm.Pop())
+        case OP_JUMP:
+            offset := readShort()
+            frame.ip += offset
+        case OP_JUMP_IF_FALSE:
+            offset := readShort()
+            if !IsTruthy(vm.PeekValue(0)) {
+                frame.ip += offset
+            }
+        case OP_LOOP:
+            offset := readShort()
+            if err := vm.in.Step(); err != nil {
+                return vm.Raise(err)
+            }
+            frame.ip -= offset
+        case OP_CALL:
+            argc := int(readByte())
+            if err := vm.in.Step(); err != nil {
+                return vm.Raise(err)
+            }
+            if err := vm.CallValue(vm.PeekValue(argc), argc); err != nil {
+                return err
+            }
+            frame = vm.frames[len(vm.frames)-1]
+            chunk = frame.closure.fn.chunk
+        case OP_CLOSURE:
+            fn := chunk.Constants[readShort()].(*FunctionObj)
+            if err := vm.in.Allocate(closureSize); err != nil {
+                return vm.Raise(err)
+            }
+            closure := &ClosureObj{fn: fn, upvalues: make([]*UpvalueObj, fn.upvalueCount)}
+            for i := range(closure.upvalues) {
+                isLocal := readByte()
+                index := int(readByte())
+                if isLocal == 1 {
+                    closure.upvalues[i] = vm.CaptureUpvalue(frame.base + index)
+                } else {
+                    closure.upvalues[i] = frame.closure.upvalues[index]
+                }
+            }
+            vm.Push(closure)
+        case OP_CLOSE_UPVALUE:
+            vm.CloseUpvalues(len(vm.stack) - 1)
+            vm.Pop()
+        case OP_RETURN:
+            result := vm.Pop()
+            vm.CloseUpvalues(frame.base)
+            vm.frames = vm.frames[:len(vm.frames)-1]
+
+            if len(vm.frames) == 0 {
+                vm.stack = vm.stack[:0]
+                vm.result = result
+                return nil
+            }
+
+            vm.stack = vm.stack[:frame.base]
+            vm.Push(result)
+
+            frame = vm.frames[len(vm.frames)-1]
+            chunk = frame.closure.fn.chunk
+        case OP_CLASS:
+            vm.Push(&ClassObj{name: readString(), methods: make(map[string]*ClosureObj)})
+        case OP_INHERIT:
+            superclass, ok := vm.PeekValue(1).(*ClassObj)
+            if !ok {
+                return vm.RuntimeError("Superclass must be a class.")
+            }
+
+            // copy down the inherited methods, the subclass may override them later
+            subclass := vm.PeekValue(0).(*ClassObj)
+            for name, method := range(superclass.methods) {
+                subclass.methods[name] = method
+            }
+            vm.Pop()
+        case OP_METHOD:
+            name := readString()
+            method := vm.Pop().(*ClosureObj)
+            vm.PeekValue(0).(*ClassObj).methods[name] = method
+        default:
+            return vm.RuntimeError("Unknown opcode %d.", op)
+        }
+    }
+}